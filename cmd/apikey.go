@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"music/internal/app"
+	"music/internal/config"
+	"music/internal/models"
+	"os"
+	"strconv"
+)
+
+const apiKeyUsage = `Usage:
+  music apikey create [-role read-only|editor|admin] NAME
+  music apikey list
+  music apikey revoke ID`
+
+// runAPIKey implements `apikey create|list|revoke`. The created key is
+// printed as JSON to stdout, it is not shown again.
+func runAPIKey(cfg config.Config, logger *slog.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, apiKeyUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("apikey "+args[0], flag.ContinueOnError)
+	role := flags.String("role", string(models.RoleEditor), "role of the created key")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), apiKeyUsage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	wantArgs := map[string]int{"create": 1, "list": 0, "revoke": 1}
+
+	n, known := wantArgs[args[0]]
+	if !known || flags.NArg() != n {
+		flags.Usage()
+		return 2
+	}
+
+	var ID int
+
+	if args[0] == "revoke" {
+		var err error
+
+		if ID, err = strconv.Atoi(flags.Arg(0)); err != nil {
+			flags.Usage()
+			return 2
+		}
+	}
+
+	keys, release, err := app.APIKeys(cfg, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer release()
+
+	ctx := context.Background()
+
+	var result any
+
+	switch args[0] {
+	case "create":
+		result, err = keys.AddAPIKey(ctx, flags.Arg(0), models.Role(*role))
+	case "list":
+		result, err = keys.GetAPIKeys(ctx)
+	case "revoke":
+		err = keys.RevokeAPIKey(ctx, ID)
+	}
+
+	if err != nil {
+		logger.Error("API key command failed", slog.String("command", args[0]), slog.String("error", err.Error()))
+		return 1
+	}
+
+	if result != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(result); err != nil {
+			logger.Error("Failed to write result", slog.String("error", err.Error()))
+			return 1
+		}
+	}
+
+	return 0
+}
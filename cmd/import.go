@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"music/internal/app"
+	"music/internal/config"
+	"music/internal/models"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// runImport implements `import [-format ndjson|csv] [-o REPORT] FILE`. The
+// report is written as JSON to stdout unless -o is given, as the debug logs
+// go there too. "-" reads the catalog from stdin.
+func runImport(cfg config.Config, logger *slog.Logger, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "catalog format, ndjson or csv (default: by file extension)")
+	output := flags.String("o", "", "write the report to this file instead of stdout")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: music import [-format ndjson|csv] [-o REPORT] FILE")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+
+	if *format == "" {
+		*format = formatByExtension(path)
+	}
+
+	file := os.Stdin
+	if path != "-" {
+		var err error
+
+		file, err = os.Open(path)
+		if err != nil {
+			logger.Error("Failed to open catalog", slog.String("error", err.Error()))
+			return 1
+		}
+		defer file.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	report, err := app.Import(ctx, cfg, logger, file, models.ImportFormat(*format))
+	if err != nil {
+		logger.Error("Failed to import catalog", slog.String("error", err.Error()))
+		return 1
+	}
+
+	if err := writeReport(*output, report); err != nil {
+		logger.Error("Failed to write report", slog.String("error", err.Error()))
+		return 1
+	}
+
+	return 0
+}
+
+func writeReport(path string, report models.ImportReport) error {
+	out := os.Stdout
+
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+func formatByExtension(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return string(models.ImportCSV)
+	default:
+		return string(models.ImportNDJSON)
+	}
+}
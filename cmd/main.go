@@ -15,8 +15,20 @@ func main() {
 
 	logger := config.SetupLogger(cfg.Mode)
 
+	// Logging outside of a request, such as by the import command, goes to
+	// the configured logger too.
+	slog.SetDefault(logger)
+
 	logger.Debug("Loaded configuration")
 
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(cfg, logger, os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		os.Exit(runAPIKey(cfg, logger, os.Args[2:]))
+	}
+
 	application := app.NewHTTPServer(cfg, logger)
 
 	logger.Info(fmt.Sprintf("Starting application on port: %s", cfg.Port))
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"music/internal/config"
+	"music/internal/service"
+)
+
+// APIKeys gives access to the API keys of the configured storage without
+// starting the server, for instance to create the first admin key. release
+// releases the storage.
+//
+// The in-memory storage belongs to the server process, keys created here
+// would be lost on return, so it is refused.
+func APIKeys(cfg config.Config, logger *slog.Logger) (keys service.Auth, release func(), err error) {
+	if cfg.DBDriver == config.DBDriverMemory {
+		return nil, nil, errors.New("API keys are not stored with DB_DRIVER=memory, " +
+			"use a Postgres database or AUTH_ENABLED=false")
+	}
+
+	db, repos := newRepository(cfg, logger)
+
+	release = func() {
+		if db != nil {
+			db.Close()
+		}
+	}
+
+	return service.NewService(repos, deferredEnrichment{}, cfg, logger).Auth, release, nil
+}
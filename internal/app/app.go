@@ -1,13 +1,19 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"music/internal/config"
 	"music/internal/controller"
+	"music/internal/enrichment"
 	"music/internal/handler"
+	"music/internal/logging"
+	"music/internal/metrics"
 	"music/internal/repository"
 	"music/internal/service"
+	"music/internal/tracing"
 	"music/migration"
 	"net/http"
 	"time"
@@ -20,11 +26,26 @@ import (
 	_ "github.com/swaggo/gin-swagger"
 )
 
+const (
+	shutdownTimeout      = 10 * time.Second
+	searchReindexTimeout = 5 * time.Minute
+)
+
 type HTTPServer struct {
 	Port       string
 	httpServer *http.Server
 	handler    http.Handler
-	db         repository.DB
+	// The metrics are served on a port of their own, which is not published
+	// with the API.
+	MetricsPort   string
+	metricsServer *http.Server
+	metrics       http.Handler
+	logger        *slog.Logger
+	db            repository.DB
+	enrichment    *service.EnrichmentQueue
+	purger        *service.TrashPurger
+	// stopTracing flushes the spans left in the exporter.
+	stopTracing func(context.Context) error
 }
 
 //	@title		Online music
@@ -38,13 +59,83 @@ type HTTPServer struct {
 
 //	@schemes	http https
 
+//	@securityDefinitions.apikey	ApiKeyAuth
+//	@in							header
+//	@name						X-API-Key
+
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+//	@description				JWT as "Bearer <token>"
+
 // @externalDocs.description	OpenAPI
 // @externalDocs.url			https://swagger.io/resources/open-api/
 func NewHTTPServer(cfg config.Config, logger *slog.Logger) *HTTPServer {
+	// Every layer logs what it does for a request with the request's
+	// attributes.
+	logger = slog.New(logging.Handler(logger.Handler()))
+
 	if cfg.Mode == config.ModeProd {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	if !cfg.AuthEnabled {
+		logger.Warn("Authentication is disabled, anyone can change the catalog")
+	}
+
+	// The tracer provider is set up before the layers take their tracers.
+	stopTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		logger.Error("Failed to set up tracing, spans are not exported", slog.String("error", err.Error()))
+		stopTracing = func(context.Context) error { return nil }
+	}
+
+	db, repos := newRepository(cfg, logger)
+
+	m := metrics.New()
+	repos.Music = repository.NewMeteredMusic(repos.Music, m)
+	if db != nil {
+		m.RegisterDB(db.GetDB())
+	}
+
+	songInfo := enrichment.NewClient(cfg, m, logger)
+	queue := service.NewEnrichmentQueue(repos.Enrichment, songInfo, cfg, logger)
+	queue.Start()
+
+	purger := service.NewTrashPurger(repos.Music, cfg, logger)
+	purger.Start()
+
+	services := service.NewService(repos, queue, cfg, logger)
+	controllers := controller.NewController(services, m, logger)
+	handlers := handler.NewHandler(controllers)
+
+	router := handlers.InitRoutes()
+
+	// Rate limits are per client IP, which is only taken from
+	// X-Forwarded-For when the request comes through a trusted proxy.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Error("Invalid trusted proxies", slog.String("error", err.Error()))
+	}
+
+	return &HTTPServer{
+		Port:        cfg.Port,
+		handler:     router,
+		MetricsPort: cfg.MetricsPort,
+		metrics:     m.Handler(),
+		logger:      logger,
+		db:          db,
+		enrichment:  queue,
+		purger:      purger,
+		stopTracing: stopTracing,
+	}
+}
+
+func newRepository(cfg config.Config, logger *slog.Logger) (repository.DB, *repository.Repository) {
+	if cfg.DBDriver == config.DBDriverMemory {
+		logger.Warn("Using in-memory storage, data will be lost on shutdown")
+		return nil, repository.NewMemoryRepository()
+	}
+
 	db := repository.NewPostgresDB(cfg)
 
 	err := migration.StartMigrate(cfg, repository.GetDBUrl(cfg), logger)
@@ -53,18 +144,43 @@ func NewHTTPServer(cfg config.Config, logger *slog.Logger) *HTTPServer {
 	}
 
 	repos := repository.NewRepository(db.GetDB())
-	services := service.NewService(repos, logger)
-	controllers := controller.NewController(services, logger)
-	handlers := handler.NewHandler(controllers)
 
-	return &HTTPServer{
-		Port:    cfg.Port,
-		handler: handlers.InitRoutes(),
-		db:      db,
+	switch cfg.RateLimitBackend {
+	case config.RateLimitPostgres:
+	case config.RateLimitMemory:
+		repos.RateLimit = repository.NewRateLimitMemory()
+	default:
+		logger.Error("Unknown rate limit backend, keeping the limits in memory",
+			slog.String("backend", cfg.RateLimitBackend))
+		repos.RateLimit = repository.NewRateLimitMemory()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), searchReindexTimeout)
+	defer cancel()
+
+	if err := repos.SetSearchLanguage(ctx, cfg.SearchLanguage); err != nil {
+		logger.Error("Failed to set search language", slog.String("error", err.Error()))
 	}
+
+	return db, repos
 }
 
 func (s *HTTPServer) Run() error {
+	if s.MetricsPort != "" {
+		s.metricsServer = &http.Server{
+			Addr:         ":" + s.MetricsPort,
+			Handler:      s.metrics,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		}
+
+		go func() {
+			if err := s.metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				s.logger.Error("Failed to serve metrics", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
 	s.httpServer = &http.Server{
 		Addr:           ":" + s.Port,
 		Handler:        s.handler,
@@ -79,9 +195,32 @@ func (s *HTTPServer) Run() error {
 func (s *HTTPServer) Shutdown() error {
 	var shutdownErr error
 
-	// Close the database connection
-	if err := s.db.Close(); err != nil {
-		shutdownErr = fmt.Errorf("failed to close database: %w", err)
+	// Stop the enrichment workers and the trash purger before the database
+	// they write to
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := s.enrichment.Stop(ctx); err != nil {
+		shutdownErr = fmt.Errorf("failed to stop enrichment queue: %w", err)
+	}
+
+	if err := s.purger.Stop(ctx); err != nil {
+		if shutdownErr != nil {
+			shutdownErr = fmt.Errorf("%v; failed to stop trash purger: %w", shutdownErr, err)
+		} else {
+			shutdownErr = fmt.Errorf("failed to stop trash purger: %w", err)
+		}
+	}
+
+	// Close the database connection, the in-memory storage has none
+	if s.db != nil {
+		if err := s.db.Close(); err != nil {
+			if shutdownErr != nil {
+				shutdownErr = fmt.Errorf("%v; failed to close database: %w", shutdownErr, err)
+			} else {
+				shutdownErr = fmt.Errorf("failed to close database: %w", err)
+			}
+		}
 	}
 
 	// Close the HTTP server
@@ -93,5 +232,25 @@ func (s *HTTPServer) Shutdown() error {
 		}
 	}
 
+	// Close the metrics server, when it is on
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Close(); err != nil {
+			if shutdownErr != nil {
+				shutdownErr = fmt.Errorf("%v; failed to close metrics server: %w", shutdownErr, err)
+			} else {
+				shutdownErr = fmt.Errorf("failed to close metrics server: %w", err)
+			}
+		}
+	}
+
+	// Flush the spans of the requests and jobs stopped above
+	if err := s.stopTracing(ctx); err != nil {
+		if shutdownErr != nil {
+			shutdownErr = fmt.Errorf("%v; failed to stop tracing: %w", shutdownErr, err)
+		} else {
+			shutdownErr = fmt.Errorf("failed to stop tracing: %w", err)
+		}
+	}
+
 	return shutdownErr
 }
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/service"
+)
+
+// Import loads a catalog into the configured storage without starting the
+// server. Songs to enrich stay pending and are picked up by the enrichment
+// queue of the next server run.
+func Import(
+	ctx context.Context, cfg config.Config, logger *slog.Logger, r io.Reader, format models.ImportFormat,
+) (models.ImportReport, error) {
+	db, repos := newRepository(cfg, logger)
+	if db != nil {
+		defer db.Close()
+	}
+
+	services := service.NewService(repos, deferredEnrichment{}, cfg, logger)
+
+	return services.ImportMusics(ctx, r, format)
+}
+
+// deferredEnrichment leaves songs to the poll of the enrichment queue.
+type deferredEnrichment struct{}
+
+func (deferredEnrichment) Enqueue(context.Context, models.EnrichmentTask) {}
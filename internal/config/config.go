@@ -3,28 +3,88 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
-var Url string
-
 const (
 	ModeLocal = "local"
 	modeDev   = "dev"
 	ModeProd  = "prod"
 )
 
+const (
+	DBDriverPostgres = "postgres"
+	DBDriverMemory   = "memory"
+)
+
+const (
+	RateLimitMemory   = "memory"
+	RateLimitPostgres = "postgres"
+)
+
+const (
+	TraceExporterNone   = "none"
+	TraceExporterStdout = "stdout"
+	TraceExporterOTLP   = "otlp"
+)
+
 type Config struct {
 	Mode string
 	Port string
+	// MetricsPort is where /metrics is served, apart from the API. Empty
+	// turns it off.
+	MetricsPort string
 
+	DBDriver   string
 	DBHost     string
 	DBPort     string
 	DBPassword string
 	DBUsername string
 	DBName     string
 	DBSSLMode  string
+
+	SearchLanguage string
+
+	EnrichmentURL              string
+	EnrichmentTimeout          time.Duration
+	EnrichmentMaxRetries       int
+	EnrichmentBackoffBase      time.Duration
+	EnrichmentBackoffMax       time.Duration
+	EnrichmentBreakerThreshold int
+	EnrichmentBreakerCooldown  time.Duration
+
+	EnrichmentWorkers      int
+	EnrichmentMaxAttempts  int
+	EnrichmentPollInterval time.Duration
+	EnrichmentRetryBase    time.Duration
+	EnrichmentRetryMax     time.Duration
+
+	IdempotencyTTL time.Duration
+
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	AuthEnabled       bool
+	AuthAnonymousRole string
+	AuthJWTSecret     string
+	AuthJWKSFile      string
+	AuthJWTIssuer     string
+	AuthJWTAudience   string
+	AuthJWTRoleClaim  string
+	AuthJWTLeeway     time.Duration
+
+	RateLimitBackend string
+	RateLimitRead    int
+	RateLimitWrite   int
+	RateLimitAuth    int
+	RateLimitPeriod  time.Duration
+	TrustedProxies   []string
+
+	TraceExporter string
 }
 
 func LoadConfig() Config {
@@ -34,11 +94,11 @@ func LoadConfig() Config {
 
 	var cfg Config
 
-	Url = getEnv("URL", "http://localhost")
-
 	cfg.Mode = getEnv("MODE", ModeLocal)
 	cfg.Port = getEnv("PORT", "8000")
+	cfg.MetricsPort = getEnv("METRICS_PORT", "9090")
 
+	cfg.DBDriver = getEnv("DB_DRIVER", DBDriverPostgres)
 	cfg.DBHost = getEnv("DB_HOST", "localhost")
 	cfg.DBPort = getEnv("DB_PORT", "5432")
 	cfg.DBPassword = getEnv("POSTGRES_PASSWORD", "postgres")
@@ -46,6 +106,45 @@ func LoadConfig() Config {
 	cfg.DBName = getEnv("POSTGRES_DB", "postgres")
 	cfg.DBSSLMode = getEnv("DB_SSLMode", "disable")
 
+	cfg.SearchLanguage = getEnv("SEARCH_LANGUAGE", "simple")
+
+	cfg.EnrichmentURL = getEnv("URL", "http://localhost")
+	cfg.EnrichmentTimeout = getEnvDuration("ENRICHMENT_TIMEOUT", 2*time.Second)
+	cfg.EnrichmentMaxRetries = getEnvInt("ENRICHMENT_MAX_RETRIES", 3)
+	cfg.EnrichmentBackoffBase = getEnvDuration("ENRICHMENT_BACKOFF_BASE", 200*time.Millisecond)
+	cfg.EnrichmentBackoffMax = getEnvDuration("ENRICHMENT_BACKOFF_MAX", 2*time.Second)
+	cfg.EnrichmentBreakerThreshold = getEnvInt("ENRICHMENT_BREAKER_THRESHOLD", 5)
+	cfg.EnrichmentBreakerCooldown = getEnvDuration("ENRICHMENT_BREAKER_COOLDOWN", 30*time.Second)
+
+	cfg.EnrichmentWorkers = getEnvInt("ENRICHMENT_WORKERS", 4)
+	cfg.EnrichmentMaxAttempts = getEnvInt("ENRICHMENT_MAX_ATTEMPTS", 5)
+	cfg.EnrichmentPollInterval = getEnvDuration("ENRICHMENT_POLL_INTERVAL", 10*time.Second)
+	cfg.EnrichmentRetryBase = getEnvDuration("ENRICHMENT_RETRY_BASE", 10*time.Second)
+	cfg.EnrichmentRetryMax = getEnvDuration("ENRICHMENT_RETRY_MAX", 10*time.Minute)
+
+	cfg.IdempotencyTTL = getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+
+	cfg.TrashRetention = getEnvDuration("TRASH_RETENTION", 30*24*time.Hour)
+	cfg.TrashPurgeInterval = getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour)
+
+	cfg.AuthEnabled = getEnvBool("AUTH_ENABLED", true)
+	cfg.AuthAnonymousRole = getEnv("AUTH_ANONYMOUS_ROLE", "read-only")
+	cfg.AuthJWTSecret = getEnv("AUTH_JWT_SECRET", "")
+	cfg.AuthJWKSFile = getEnv("AUTH_JWKS_FILE", "")
+	cfg.AuthJWTIssuer = getEnv("AUTH_JWT_ISSUER", "")
+	cfg.AuthJWTAudience = getEnv("AUTH_JWT_AUDIENCE", "")
+	cfg.AuthJWTRoleClaim = getEnv("AUTH_JWT_ROLE_CLAIM", "role")
+	cfg.AuthJWTLeeway = getEnvDuration("AUTH_JWT_LEEWAY", time.Minute)
+
+	cfg.RateLimitBackend = getEnv("RATE_LIMIT_BACKEND", RateLimitMemory)
+	cfg.RateLimitRead = getEnvInt("RATE_LIMIT_READ", 300)
+	cfg.RateLimitWrite = getEnvInt("RATE_LIMIT_WRITE", 60)
+	cfg.RateLimitAuth = getEnvInt("RATE_LIMIT_AUTH", 600)
+	cfg.RateLimitPeriod = getEnvDuration("RATE_LIMIT_PERIOD", time.Minute)
+	cfg.TrustedProxies = getEnvList("TRUSTED_PROXIES")
+
+	cfg.TraceExporter = getEnv("TRACE_EXPORTER", TraceExporterNone)
+
 	return cfg
 }
 
@@ -57,3 +156,62 @@ func getEnv(key, defaultValue string) string {
 
 	return value
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default value %d", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return i
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default value %t", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return b
+}
+
+// getEnvList reads a comma separated list, empty when the variable is not
+// set.
+func getEnvList(key string) []string {
+	var list []string
+
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using default value %s", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return d
+}
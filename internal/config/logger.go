@@ -13,8 +13,8 @@ func SetupLogger(mode string) *slog.Logger {
 	var logFile *os.File
 	var err error
 
-	if mode == ModeProd {
-		logFile, err = os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_RDONLY, 0600)
+	if mode != ModeLocal {
+		logFile, err = os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 		if err != nil {
 			log.Printf("failed to open log file: %v", err)
 			logFile = os.Stdout
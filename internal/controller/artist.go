@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"log/slog"
+	"music/internal/models"
+	"music/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Artist interface {
+	GetArtists(ctx *gin.Context)
+	GetArtist(ctx *gin.Context)
+	GetArtistSongs(ctx *gin.Context)
+	AddArtist(ctx *gin.Context)
+	RenameArtist(ctx *gin.Context)
+	DeleteArtist(ctx *gin.Context)
+}
+
+type artistController struct {
+	service service.Artist
+	logger  *slog.Logger
+}
+
+func newArtistController(service service.Artist, logger *slog.Logger) *artistController {
+	return &artistController{service: service, logger: logger}
+}
+
+// @Summary	Get artists
+// @Tags		artist
+// @Accept		json
+// @Produce	json
+// @Param		offset	query		int	false	"offset"
+// @Param		limit	query		int	false	"limit"
+// @Success	200		{array}		models.Artist
+// @Failure	400		{object}	ErrorResponse
+// @Failure	429		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/artists [get]
+func (c *artistController) GetArtists(ctx *gin.Context) {
+	limit, offset, ok := parsePagination(ctx, c.logger)
+	if !ok {
+		return
+	}
+
+	artists, err := c.service.GetArtists(ctx, limit, offset)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to get artists", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, artists)
+}
+
+// @Summary	Get artist
+// @Tags		artist
+// @Accept		json
+// @Produce	json
+// @Param		artist_id	path		int	true	"artist ID int"
+// @Success	200			{object}	models.Artist
+// @Failure	400			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/artists/{artist_id} [get]
+func (c *artistController) GetArtist(ctx *gin.Context) {
+	artistID, ok := parseIDParam(ctx, c.logger, "artist_id")
+	if !ok {
+		return
+	}
+
+	artist, err := c.service.GetArtist(ctx, artistID)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to get artist", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, artist)
+}
+
+// @Summary	Get artist songs
+// @Tags		artist
+// @Accept		json
+// @Produce	json
+// @Param		artist_id	path		int	true	"artist ID int"
+// @Param		offset		query		int	false	"offset"
+// @Param		limit		query		int	false	"limit"
+// @Success	200			{array}		models.MusicInfo
+// @Failure	400			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/artists/{artist_id}/songs [get]
+func (c *artistController) GetArtistSongs(ctx *gin.Context) {
+	artistID, ok := parseIDParam(ctx, c.logger, "artist_id")
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePagination(ctx, c.logger)
+	if !ok {
+		return
+	}
+
+	musics, err := c.service.GetArtistSongs(ctx, artistID, limit, offset)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to get artist songs", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, musics)
+}
+
+// @Summary	Add artist
+// @Tags		artist
+// @Accept		json
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		request	body		models.ArtistInput	true	"body json"
+// @Success	201		{object}	models.Artist
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	409		{object}	ErrorResponse
+// @Failure	422		{object}	ErrorResponse
+// @Failure	429		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/artists [post]
+func (c *artistController) AddArtist(ctx *gin.Context) {
+	var input models.ArtistInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.logger.DebugContext(ctx, "Error on parse body params", slog.String("error", err.Error()))
+		abortWithBadRequest(ctx, "Invalid input: "+err.Error())
+		return
+	}
+
+	artist, err := c.service.AddArtist(ctx, input.Name)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to add artist", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, artist)
+}
+
+// @Summary	Rename artist
+// @Description	Renames the artist and all of its songs. Renaming to the name of
+// @Description	another artist merges the songs into that artist and returns it.
+// @Tags		artist
+// @Accept		json
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		artist_id	path		int					true	"artist ID int"
+// @Param		request		body		models.ArtistInput	true	"body json"
+// @Success	200			{object}	models.Artist
+// @Failure	400			{object}	ErrorResponse
+// @Failure	401			{object}	ErrorResponse
+// @Failure	403			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	409			{object}	ErrorResponse
+// @Failure	422			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/artists/{artist_id} [patch]
+func (c *artistController) RenameArtist(ctx *gin.Context) {
+	artistID, ok := parseIDParam(ctx, c.logger, "artist_id")
+	if !ok {
+		return
+	}
+
+	var input models.ArtistInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.logger.DebugContext(ctx, "Error on parsing body", slog.String("error", err.Error()))
+		abortWithBadRequest(ctx, "Invalid input: "+err.Error())
+		return
+	}
+
+	artist, err := c.service.RenameArtist(ctx, artistID, input.Name)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to rename artist", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, artist)
+}
+
+// @Summary	Delete artist
+// @Description	Only artists without songs can be deleted.
+// @Tags		artist
+// @Accept		json
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		artist_id	path		int	true	"artist ID int"
+// @Success	204			{string}	Success
+// @Failure	400			{object}	ErrorResponse
+// @Failure	401			{object}	ErrorResponse
+// @Failure	403			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	409			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/artists/{artist_id} [delete]
+func (c *artistController) DeleteArtist(ctx *gin.Context) {
+	artistID, ok := parseIDParam(ctx, c.logger, "artist_id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.DeleteArtist(ctx, artistID); err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to delete artist", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"music/internal/models"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeader        = "X-Request-ID"
+	maxRequestIDLength     = 128
+	generatedRequestIDSize = 16
+)
+
+type Audit interface {
+	// Audited is a middleware that gives the request an ID, the one sent in
+	// X-Request-ID or a new one, and puts it into the request context for
+	// the history of the songs the request changes. The ID is sent back in
+	// X-Request-ID.
+	Audited(ctx *gin.Context)
+}
+
+type auditController struct {
+	logger *slog.Logger
+}
+
+func newAuditController(logger *slog.Logger) *auditController {
+	return &auditController{logger: logger}
+}
+
+func (c *auditController) Audited(ctx *gin.Context) {
+	requestID := strings.TrimSpace(ctx.GetHeader(requestIDHeader))
+	if !validRequestID(requestID) {
+		if requestID != "" {
+			c.logger.DebugContext(ctx, "Invalid request ID replaced", slog.String("request_id", requestID))
+		}
+
+		requestID = newRequestID()
+	}
+
+	audit := models.AuditFrom(ctx.Request.Context())
+	audit.RequestID = requestID
+
+	ctx.Request = ctx.Request.WithContext(models.WithAudit(ctx.Request.Context(), audit))
+	ctx.Header(requestIDHeader, requestID)
+
+	ctx.Next()
+}
+
+// validRequestID accepts IDs of printable ASCII that fit the history.
+func validRequestID(ID string) bool {
+	if ID == "" || len(ID) > maxRequestIDLength {
+		return false
+	}
+
+	return !strings.ContainsFunc(ID, func(r rune) bool {
+		return r > unicode.MaxASCII || !unicode.IsPrint(r)
+	})
+}
+
+func newRequestID() string {
+	ID := make([]byte, generatedRequestIDSize)
+	_, _ = rand.Read(ID)
+
+	return hex.EncodeToString(ID)
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"log/slog"
+	"music/internal/logging"
+	"music/internal/models"
+	"music/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyHeader          = "X-API-Key"
+	bearerScheme          = "Bearer"
+	wwwAuthenticateHeader = "WWW-Authenticate"
+	authChallenge         = `Bearer realm="music", ApiKey realm="music"`
+)
+
+type Auth interface {
+	// Authenticated is a middleware that reads the credentials of the
+	// request, an X-API-Key header or an Authorization bearer token, and puts
+	// the principal into the request context. The subject of the principal
+	// becomes the actor of the changes made by the request. Invalid
+	// credentials fail with 401, missing ones make the request anonymous.
+	Authenticated(ctx *gin.Context)
+	// Require returns a middleware that lets only principals with at least
+	// role through. Anonymous requests are answered with 401, others with
+	// 403.
+	Require(role models.Role) gin.HandlerFunc
+
+	GetAPIKeys(ctx *gin.Context)
+	AddAPIKey(ctx *gin.Context)
+	RevokeAPIKey(ctx *gin.Context)
+}
+
+type authController struct {
+	service service.Auth
+	logger  *slog.Logger
+}
+
+func newAuthController(service service.Auth, logger *slog.Logger) *authController {
+	return &authController{service: service, logger: logger}
+}
+
+func (c *authController) Authenticated(ctx *gin.Context) {
+	credentials, ok := c.credentials(ctx)
+	if !ok {
+		return
+	}
+
+	principal, err := c.service.Authenticate(ctx, credentials)
+	if err != nil {
+		ctx.Header(wwwAuthenticateHeader, authChallenge)
+		abortWithServiceError(ctx, c.logger, "Failed to authenticate", err)
+		return
+	}
+
+	reqCtx := models.WithPrincipal(ctx.Request.Context(), principal)
+
+	if principal.Authenticated() {
+		audit := models.AuditFrom(reqCtx)
+		audit.Actor = principal.Subject
+		reqCtx = models.WithAudit(reqCtx, audit)
+		reqCtx = logging.With(reqCtx, slog.String("principal", principal.Subject))
+	}
+
+	ctx.Request = ctx.Request.WithContext(reqCtx)
+
+	ctx.Next()
+}
+
+// credentials reads the X-API-Key header or the bearer token of the
+// Authorization header. Sending both, or another Authorization scheme, is
+// rejected with 400 and ok == false.
+func (c *authController) credentials(ctx *gin.Context) (credentials models.Credentials, ok bool) {
+	credentials.APIKey = strings.TrimSpace(ctx.GetHeader(apiKeyHeader))
+
+	if header := strings.TrimSpace(ctx.GetHeader("Authorization")); header != "" {
+		scheme, token, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, bearerScheme) {
+			c.logger.DebugContext(ctx, "Unsupported authorization scheme", slog.String("scheme", scheme))
+			abortWithBadRequest(ctx, "Authorization must be a bearer token")
+			return credentials, false
+		}
+
+		credentials.Token = strings.TrimSpace(token)
+	}
+
+	if credentials.APIKey != "" && credentials.Token != "" {
+		abortWithBadRequest(ctx, "Send either X-API-Key or a bearer token, not both")
+		return credentials, false
+	}
+
+	return credentials, true
+}
+
+func (c *authController) Require(role models.Role) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal := models.PrincipalFrom(ctx)
+
+		switch {
+		case principal.Role.Includes(role):
+			ctx.Next()
+		case principal.Authenticated():
+			c.logger.DebugContext(ctx, "Insufficient role",
+				slog.String("subject", principal.Subject), slog.String("role", string(principal.Role)))
+			abortWithError(ctx, http.StatusForbidden, codeForbidden, "This requires the "+string(role)+" role")
+		default:
+			ctx.Header(wwwAuthenticateHeader, authChallenge)
+			abortWithError(ctx, http.StatusUnauthorized, codeUnauthorized, "Authentication required")
+		}
+	}
+}
+
+// @Summary	Get API keys
+// @Description	Lists the API keys, revoked ones included. Keys themselves are
+// @Description	never returned after their creation.
+// @Tags		auth
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Success	200	{array}		models.APIKey
+// @Failure	401	{object}	ErrorResponse
+// @Failure	403	{object}	ErrorResponse
+// @Failure	429	{object}	ErrorResponse
+// @Failure	500	{object}	ErrorResponse
+// @Router		/api-keys [get]
+func (c *authController) GetAPIKeys(ctx *gin.Context) {
+	keys, err := c.service.GetAPIKeys(ctx)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to get API keys", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// @Summary	Add API key
+// @Description	Creates an API key. The key is only returned in this response.
+// @Tags		auth
+// @Accept		json
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		request	body		models.APIKeyInput	true	"body json"
+// @Success	201		{object}	models.APIKey
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	409		{object}	ErrorResponse
+// @Failure	422		{object}	ErrorResponse
+// @Failure	429		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/api-keys [post]
+func (c *authController) AddAPIKey(ctx *gin.Context) {
+	var input models.APIKeyInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		c.logger.DebugContext(ctx, "Error on parsing body", slog.String("error", err.Error()))
+		abortWithBadRequest(ctx, "Invalid input: "+err.Error())
+		return
+	}
+
+	key, err := c.service.AddAPIKey(ctx, input.Name, input.Role)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to add API key", err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, key)
+}
+
+// @Summary	Revoke API key
+// @Tags		auth
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		key_id	path		int	true	"API key ID int"
+// @Success	204		{string}	Success
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	404		{object}	ErrorResponse
+// @Failure	429		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/api-keys/{key_id} [delete]
+func (c *authController) RevokeAPIKey(ctx *gin.Context) {
+	keyID, ok := parseIDParam(ctx, c.logger, "key_id")
+	if !ok {
+		return
+	}
+
+	if err := c.service.RevokeAPIKey(ctx, keyID); err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to revoke API key", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"music/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeys is a service.Auth knowing the principals of a few API keys.
+type apiKeys struct {
+	principals map[string]models.Principal
+	anonymous  models.Role
+}
+
+func (s apiKeys) Authenticate(ctx context.Context, credentials models.Credentials) (models.Principal, error) {
+	if credentials.APIKey == "" && credentials.Token == "" {
+		return models.Principal{Role: s.anonymous}, nil
+	}
+
+	principal, ok := s.principals[credentials.APIKey]
+	if !ok {
+		return models.Principal{}, fmt.Errorf("%w: unknown or revoked API key", models.ErrUnauthorized)
+	}
+
+	return principal, nil
+}
+
+func (apiKeys) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) { return nil, nil }
+
+func (apiKeys) AddAPIKey(ctx context.Context, name string, role models.Role) (models.APIKey, error) {
+	return models.APIKey{}, nil
+}
+
+func (apiKeys) RevokeAPIKey(ctx context.Context, ID int) error { return nil }
+
+// newAuthRouter serves GET /songs to readers, POST /songs to editors and
+// GET /api-keys to admins, the way the handler groups its routes.
+func newAuthRouter(anonymous models.Role) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	c := newAuthController(apiKeys{
+		principals: map[string]models.Principal{
+			"reader": {Subject: "reader", Role: models.RoleReadOnly, Method: models.AuthAPIKey},
+			"editor": {Subject: "editor", Role: models.RoleEditor, Method: models.AuthAPIKey},
+			"admin":  {Subject: "admin", Role: models.RoleAdmin, Method: models.AuthAPIKey},
+		},
+		anonymous: anonymous,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(c.Authenticated)
+	router.GET("/songs", c.Require(models.RoleReadOnly), ok)
+	router.POST("/songs", c.Require(models.RoleEditor), ok)
+	router.GET("/api-keys", c.Require(models.RoleAdmin), ok)
+
+	return router
+}
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name      string
+		anonymous models.Role
+		key       string
+		want      [3]int // GET /songs, POST /songs, GET /api-keys
+	}{
+		{"anonymous", "", "", [3]int{401, 401, 401}},
+		{"anonymous reader", models.RoleReadOnly, "", [3]int{200, 401, 401}},
+		{"unknown or revoked key", models.RoleReadOnly, "revoked", [3]int{401, 401, 401}},
+		{"reader", "", "reader", [3]int{200, 403, 403}},
+		{"editor", "", "editor", [3]int{200, 200, 403}},
+		{"admin", "", "admin", [3]int{200, 200, 200}},
+	}
+
+	routes := [3]struct{ method, path string }{
+		{http.MethodGet, "/songs"},
+		{http.MethodPost, "/songs"},
+		{http.MethodGet, "/api-keys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newAuthRouter(tt.anonymous)
+
+			for i, route := range routes {
+				req := httptest.NewRequest(route.method, route.path, nil)
+				if tt.key != "" {
+					req.Header.Set(apiKeyHeader, tt.key)
+				}
+
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != tt.want[i] {
+					t.Errorf("%s %s = %d, want %d", route.method, route.path, w.Code, tt.want[i])
+				}
+
+				challenged := w.Header().Get(wwwAuthenticateHeader) != ""
+				if challenged != (w.Code == http.StatusUnauthorized) {
+					t.Errorf("%s %s = %d with WWW-Authenticate %q, want a challenge only on 401",
+						route.method, route.path, w.Code, w.Header().Get(wwwAuthenticateHeader))
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticatedRejectsMixedCredentials(t *testing.T) {
+	router := newAuthRouter(models.RoleReadOnly)
+
+	tests := []struct {
+		name   string
+		header http.Header
+	}{
+		{"API key and token", http.Header{"X-Api-Key": {"admin"}, "Authorization": {"Bearer token"}}},
+		{"basic auth", http.Header{"Authorization": {"Basic YWRtaW46YWRtaW4="}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/songs", nil)
+			req.Header = tt.header
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("GET /songs = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -2,15 +2,36 @@ package controller
 
 import (
 	"log/slog"
+	"music/internal/metrics"
 	"music/internal/service"
 )
 
 type Controller struct {
 	Music
+	Artist
+	Import
+	Export
+	Idempotency
+	Audit
+	Auth
+	RateLimit
+	Logging
+	Metrics
+	Tracing
 }
 
-func NewController(services *service.Service, logger *slog.Logger) *Controller {
+func NewController(services *service.Service, m *metrics.Metrics, logger *slog.Logger) *Controller {
 	return &Controller{
-		Music: newMusicController(services.Music, logger),
+		Music:       newMusicController(services.Music, logger),
+		Artist:      newArtistController(services.Artist, logger),
+		Import:      newImportController(services.Import, logger),
+		Export:      newExportController(services.Music, logger),
+		Idempotency: newIdempotencyController(services.Idempotency, logger),
+		Audit:       newAuditController(logger),
+		Auth:        newAuthController(services.Auth, logger),
+		RateLimit:   newRateLimitController(services.RateLimit, logger),
+		Logging:     newLoggingController(logger),
+		Metrics:     newMetricsController(m),
+		Tracing:     newTracingController(),
 	}
 }
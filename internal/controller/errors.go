@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"music/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	codeBadRequest           = "bad_request"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeNoUpdates            = "no_updates"
+	codeConflict             = "conflict"
+	codeValidation           = "validation_failed"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeOutOfRange           = "out_of_range"
+	codePreconditionFailed   = "precondition_failed"
+	codeInternal             = "internal_error"
+)
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// ID is the existing song on a duplicate song conflict.
+	ID int `json:"id,omitempty"`
+}
+
+var domainErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{models.ErrNotFound, http.StatusNotFound, codeNotFound},
+	{models.ErrNoUpdates, http.StatusBadRequest, codeNoUpdates},
+	{models.ErrConflict, http.StatusConflict, codeConflict},
+	{models.ErrValidation, http.StatusUnprocessableEntity, codeValidation},
+	{models.ErrOutOfRange, http.StatusRequestedRangeNotSatisfiable, codeOutOfRange},
+	{models.ErrPreconditionFailed, http.StatusPreconditionFailed, codePreconditionFailed},
+	{models.ErrUnauthorized, http.StatusUnauthorized, codeUnauthorized},
+}
+
+func abortWithError(ctx *gin.Context, status int, code, message string) {
+	ctx.AbortWithStatusJSON(status, ErrorResponse{Code: code, Message: message})
+}
+
+func abortWithBadRequest(ctx *gin.Context, message string) {
+	abortWithError(ctx, http.StatusBadRequest, codeBadRequest, message)
+}
+
+// abortWithBindError reports a body that could not be decoded. Values the
+// models reject, such as invalid dates, fail with 422, malformed bodies with
+// 400.
+func abortWithBindError(ctx *gin.Context, logger *slog.Logger, err error) {
+	if errors.Is(err, models.ErrValidation) {
+		abortWithServiceError(ctx, logger, "Invalid body", err)
+		return
+	}
+
+	logger.DebugContext(ctx, "Error on parsing body", slog.String("error", err.Error()))
+	abortWithBadRequest(ctx, err.Error())
+}
+
+// abortWithServiceError maps an error returned by the service layer to its
+// HTTP status. Unknown errors become a 500 without leaking their details.
+func abortWithServiceError(ctx *gin.Context, logger *slog.Logger, msg string, err error) {
+	status, code, message := http.StatusInternalServerError, codeInternal, "Internal Server Error"
+
+	for _, e := range domainErrors {
+		if errors.Is(err, e.err) {
+			status, code, message = e.status, e.code, err.Error()
+			break
+		}
+	}
+
+	if status >= http.StatusInternalServerError {
+		logger.ErrorContext(ctx, msg, slog.String("error", err.Error()))
+	} else {
+		logger.DebugContext(ctx, msg, slog.String("error", err.Error()))
+	}
+
+	response := ErrorResponse{Code: code, Message: message}
+
+	var exists *models.SongExistsError
+	if errors.As(err, &exists) {
+		response.ID = exists.ID
+	}
+
+	ctx.AbortWithStatusJSON(status, response)
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"log/slog"
+	"music/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// musicETag returns the entity tag of music, made of its version.
+func musicETag(music models.MusicInfo) string {
+	return `"` + strconv.Itoa(music.Version) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag or is "*".
+// Tags are compared weakly, ignoring the W/ prefix.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIfMatch returns the song version required by the If-Match header, 0
+// when there is no header or it is "*". Weak and malformed tags can never
+// match, as If-Match compares strongly, so the request fails with 412. Only
+// a single tag is supported, a list is rejected with 400. On failure the
+// request is aborted and ok == false.
+func parseIfMatch(ctx *gin.Context, logger *slog.Logger) (version int, ok bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	if strings.Contains(header, ",") {
+		logger.DebugContext(ctx, "Invalid If-Match", slog.String("if_match", header))
+		abortWithBadRequest(ctx, "If-Match must be a single entity tag or *")
+		return 0, false
+	}
+
+	tag, opened := strings.CutPrefix(header, `"`)
+	tag, closed := strings.CutSuffix(tag, `"`)
+
+	version, err := strconv.Atoi(tag)
+	if !opened || !closed || err != nil || version < 1 {
+		abortWithError(ctx, http.StatusPreconditionFailed, codePreconditionFailed, "If-Match does not match the song")
+		return 0, false
+	}
+
+	return version, true
+}
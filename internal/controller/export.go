@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"music/internal/models"
+	"music/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Export interface {
+	ExportMusics(ctx *gin.Context)
+}
+
+type exportController struct {
+	service service.Music
+	logger  *slog.Logger
+}
+
+func newExportController(service service.Music, logger *slog.Logger) *exportController {
+	return &exportController{service: service, logger: logger}
+}
+
+// exportFormats maps the format query parameter to the content type and the
+// encoder of the export.
+var exportFormats = map[string]struct {
+	contentType string
+	newEncoder  func(w io.Writer) exportEncoder
+}{
+	"json":   {"application/json; charset=utf-8", newJSONExport},
+	"ndjson": {"application/x-ndjson; charset=utf-8", newNDJSONExport},
+	"csv":    {"text/csv; charset=utf-8", newCSVExport},
+}
+
+// @Summary	Export musics
+// @Description	Streams every song matching the filters of the list endpoint as a file.
+// @Description	The CSV and NDJSON exports can be imported back with POST /import.
+// @Tags		import
+// @Produce	json
+// @Produce	application/x-ndjson
+// @Produce	text/csv
+// @Param		format			query		string	false	"Format, json by default"	Enums(json, ndjson, csv)
+// @Param		group			query		string	false	"Group"
+// @Param		group_match		query		string	false	"Group match mode"	Enums(contains, exact)
+// @Param		song			query		string	false	"Song name"
+// @Param		song_match		query		string	false	"Song match mode"	Enums(contains, exact)
+// @Param		text			query		string	false	"Text"
+// @Param		text_match		query		string	false	"Text match mode"	Enums(contains, exact)
+// @Param		release_date	query		string	false	"Release date, YYYY-MM-DD"
+// @Param		released_after	query		string	false	"Released on or after, YYYY-MM-DD"
+// @Param		released_before	query		string	false	"Released on or before, YYYY-MM-DD"
+// @Param		sort			query		string	false	"Comma separated group, song, release_date or id, each with optional :asc or :desc"
+// @Success	200				{array}		models.MusicInfo
+// @Failure	400				{object}	ErrorResponse
+// @Failure	429				{object}	ErrorResponse
+// @Failure	500				{object}	ErrorResponse
+// @Router		/export [get]
+func (c *exportController) ExportMusics(ctx *gin.Context) {
+	name := ctx.DefaultQuery("format", "json")
+
+	format, ok := exportFormats[name]
+	if !ok {
+		c.logger.DebugContext(ctx, "Invalid export format", slog.String("format", name))
+		abortWithBadRequest(ctx, "Invalid format, expected json, ndjson or csv")
+		return
+	}
+
+	filter, ok := parseMusicFilter(ctx, c.logger)
+	if !ok {
+		return
+	}
+
+	// A large export outlives the write timeout of the server.
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	filename := fmt.Sprintf("songs-%s.%s", time.Now().Format("20060102"), name)
+
+	ctx.Header("Content-Type", format.contentType)
+	ctx.Header("Content-Disposition", "attachment; filename="+strconv.Quote(filename))
+
+	encoder := format.newEncoder(ctx.Writer)
+
+	// The request context, unlike gin's, is canceled when the client leaves.
+	err := c.service.ExportMusics(ctx.Request.Context(), filter, encoder.encode)
+	if err == nil {
+		err = encoder.close()
+	}
+
+	if err != nil {
+		if ctx.Writer.Written() {
+			// The status is already sent, the client sees a truncated file.
+			c.logger.WarnContext(ctx, "Export interrupted", slog.String("error", err.Error()))
+			return
+		}
+
+		ctx.Header("Content-Type", "")
+		ctx.Header("Content-Disposition", "")
+		abortWithServiceError(ctx, c.logger, "Failed to export musics", err)
+	}
+}
+
+// exportEncoder writes songs one by one. Nothing is written before the
+// first song or close, so that an early error can still get its status.
+type exportEncoder interface {
+	encode(music models.MusicInfo) error
+	close() error
+}
+
+// jsonExport writes a JSON array.
+type jsonExport struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONExport(w io.Writer) exportEncoder {
+	return &jsonExport{w: w}
+}
+
+func (e *jsonExport) encode(music models.MusicInfo) error {
+	data, err := json.Marshal(music)
+	if err != nil {
+		return err
+	}
+
+	separator := ","
+	if e.count == 0 {
+		separator = "["
+	}
+
+	e.count++
+
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+
+	return err
+}
+
+func (e *jsonExport) close() error {
+	end := "]"
+	if e.count == 0 {
+		end = "[]"
+	}
+
+	_, err := io.WriteString(e.w, end)
+
+	return err
+}
+
+type ndjsonExport struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONExport(w io.Writer) exportEncoder {
+	return &ndjsonExport{encoder: json.NewEncoder(w)}
+}
+
+func (e *ndjsonExport) encode(music models.MusicInfo) error {
+	return e.encoder.Encode(music)
+}
+
+func (e *ndjsonExport) close() error {
+	return nil
+}
+
+// csvExport writes a CSV file with a header row, its columns are those of
+// the CSV import plus id and enrichment_status.
+type csvExport struct {
+	w      *csv.Writer
+	header bool
+}
+
+var csvExportHeader = []string{"id", "group", "song", "release_date", "text", "link", "enrichment_status"}
+
+func newCSVExport(w io.Writer) exportEncoder {
+	return &csvExport{w: csv.NewWriter(w)}
+}
+
+func (e *csvExport) encode(music models.MusicInfo) error {
+	if !e.header {
+		e.header = true
+
+		if err := e.w.Write(csvExportHeader); err != nil {
+			return err
+		}
+	}
+
+	return e.w.Write([]string{
+		strconv.Itoa(music.ID),
+		music.Group,
+		music.Song,
+		music.RelaseDate.String(),
+		music.Text,
+		music.Link,
+		music.EnrichmentStatus,
+	})
+}
+
+func (e *csvExport) close() error {
+	if !e.header {
+		e.header = true
+
+		if err := e.w.Write(csvExportHeader); err != nil {
+			return err
+		}
+	}
+
+	e.w.Flush()
+
+	return e.w.Error()
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"bytes"
+	"io"
+	"music/internal/models"
+	"testing"
+	"time"
+)
+
+func TestExportEncoders(t *testing.T) {
+	musics := []models.MusicInfo{
+		{
+			ID: 1, Group: "Muse", Song: "Uprising", RelaseDate: models.NewDate(2009, time.September, 14),
+			Text: "Paranoia is in bloom,\nthe PR transmissions will resume", Link: "https://example.com/uprising",
+			EnrichmentStatus: models.EnrichmentEnriched, Version: 2,
+		},
+		{ID: 2, Group: "Muse", Song: `"Resistance"`, EnrichmentStatus: models.EnrichmentPending, Version: 1},
+	}
+
+	tests := []struct {
+		name       string
+		newEncoder func(w io.Writer) exportEncoder
+		want       string
+		wantEmpty  string
+	}{
+		{
+			name:       "json",
+			newEncoder: newJSONExport,
+			want: `[{"id":1,"group":"Muse","song":"Uprising","release_date":"2009-09-14",` +
+				`"text":"Paranoia is in bloom,\nthe PR transmissions will resume","link":"https://example.com/uprising",` +
+				`"enrichment_status":"enriched","version":2},` +
+				`{"id":2,"group":"Muse","song":"\"Resistance\"","release_date":"","text":"","link":"",` +
+				`"enrichment_status":"pending","version":1}]`,
+			wantEmpty: `[]`,
+		},
+		{
+			name:       "ndjson",
+			newEncoder: newNDJSONExport,
+			want: `{"id":1,"group":"Muse","song":"Uprising","release_date":"2009-09-14",` +
+				`"text":"Paranoia is in bloom,\nthe PR transmissions will resume","link":"https://example.com/uprising",` +
+				`"enrichment_status":"enriched","version":2}` + "\n" +
+				`{"id":2,"group":"Muse","song":"\"Resistance\"","release_date":"","text":"","link":"",` +
+				`"enrichment_status":"pending","version":1}` + "\n",
+			wantEmpty: ``,
+		},
+		{
+			name:       "csv",
+			newEncoder: newCSVExport,
+			want: "id,group,song,release_date,text,link,enrichment_status\n" +
+				"1,Muse,Uprising,2009-09-14,\"Paranoia is in bloom,\nthe PR transmissions will resume\",https://example.com/uprising,enriched\n" +
+				"2,Muse,\"\"\"Resistance\"\"\",,,,pending\n",
+			wantEmpty: "id,group,song,release_date,text,link,enrichment_status\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			encoder := tt.newEncoder(&buf)
+			if buf.Len() != 0 {
+				t.Fatalf("wrote %q before the first song", buf.String())
+			}
+
+			for _, music := range musics {
+				if err := encoder.encode(music); err != nil {
+					t.Fatalf("encode(%+v): %v", music, err)
+				}
+			}
+
+			if err := encoder.close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("export =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+
+		t.Run(tt.name+" empty", func(t *testing.T) {
+			var buf bytes.Buffer
+
+			if err := tt.newEncoder(&buf).close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			if got := buf.String(); got != tt.wantEmpty {
+				t.Errorf("empty export = %q, want %q", got, tt.wantEmpty)
+			}
+		})
+	}
+}
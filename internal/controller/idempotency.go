@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log/slog"
+	"music/internal/models"
+	"music/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// replayedHeaders are the response headers stored with an idempotent
+// response and sent again on replay.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+type Idempotency interface {
+	// Idempotent is a middleware for POST requests with an Idempotency-Key
+	// header: the first request runs and its response is stored, a retry
+	// of the same principal with the same key gets the stored response
+	// instead. The body is hashed as the handler streams it.
+	Idempotent(ctx *gin.Context)
+}
+
+type idempotencyController struct {
+	service service.Idempotency
+	logger  *slog.Logger
+}
+
+func newIdempotencyController(service service.Idempotency, logger *slog.Logger) *idempotencyController {
+	return &idempotencyController{service: service, logger: logger}
+}
+
+func (c *idempotencyController) Idempotent(ctx *gin.Context) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" || ctx.Request.Method != http.MethodPost {
+		ctx.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		abortWithBadRequest(ctx, "Idempotency-Key must be at most 255 bytes")
+		return
+	}
+
+	// The body is not known yet, the key is reserved with the fingerprint of
+	// the target and stored with that of the whole request.
+	target := targetFingerprint(ctx.Request)
+
+	record, reserved, err := c.service.ReserveIdempotencyKey(ctx, key, target)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to reserve idempotency key", err)
+		return
+	}
+
+	if !reserved {
+		c.replay(ctx, record, target)
+		return
+	}
+
+	body := newFingerprintedBody(ctx.Request.Body, target)
+	ctx.Request.Body = body
+
+	recorder := &responseRecorder{ResponseWriter: ctx.Writer, request: body}
+	ctx.Writer = recorder
+
+	// The key is settled even when the client went away meanwhile.
+	settle := context.WithoutCancel(ctx)
+
+	// A key left unsettled, after a panic or a server error, is released so
+	// that the client may retry with it.
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+
+		if err := c.service.ReleaseIdempotencyKey(settle, key); err != nil {
+			c.logger.ErrorContext(ctx, "Failed to release idempotency key", slog.String("error", err.Error()))
+		}
+	}()
+
+	ctx.Next()
+
+	if recorder.Status() >= http.StatusInternalServerError {
+		return
+	}
+
+	fingerprint, err := body.Sum()
+	if err != nil {
+		c.logger.DebugContext(ctx, "Failed to read body", slog.String("error", err.Error()))
+		return
+	}
+
+	record = models.IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Status:      recorder.Status(),
+		Header:      make(map[string]string),
+		Body:        recorder.body.Bytes(),
+	}
+
+	for _, name := range replayedHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			record.Header[name] = value
+		}
+	}
+
+	if err := c.service.CompleteIdempotencyKey(settle, key, record); err != nil {
+		c.logger.ErrorContext(ctx, "Failed to store idempotent response", slog.String("error", err.Error()))
+		return
+	}
+
+	completed = true
+}
+
+// replay answers a request whose key is already reserved. A request in
+// progress only has the fingerprint of its target, a completed one that of
+// the whole request.
+func (c *idempotencyController) replay(ctx *gin.Context, record models.IdempotencyRecord, target string) {
+	fingerprint := target
+	if record.Completed {
+		var err error
+		if fingerprint, err = newFingerprintedBody(ctx.Request.Body, target).Sum(); err != nil {
+			c.logger.DebugContext(ctx, "Failed to read body", slog.String("error", err.Error()))
+			abortWithBadRequest(ctx, "Failed to read body")
+			return
+		}
+	}
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		abortWithError(ctx, http.StatusUnprocessableEntity, codeValidation,
+			"Idempotency-Key was already used for another request")
+	case !record.Completed:
+		abortWithError(ctx, http.StatusConflict, codeConflict,
+			"A request with this Idempotency-Key is in progress")
+	default:
+		for name, value := range record.Header {
+			ctx.Header(name, value)
+		}
+
+		ctx.Header(idempotentReplayedHeader, "true")
+		ctx.Status(record.Status)
+		_, _ = ctx.Writer.Write(record.Body)
+		ctx.Abort()
+	}
+}
+
+// targetFingerprint hashes the method and URL of a request. Keys are kept
+// per principal, so who sends it needs no hashing.
+func targetFingerprint(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.RequestURI()))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprintedBody hashes a request body as it is read, so that the body
+// is fingerprinted without being held in memory.
+type fingerprintedBody struct {
+	io.ReadCloser
+	hash hash.Hash
+	eof  bool
+	err  error
+}
+
+func newFingerprintedBody(body io.ReadCloser, target string) *fingerprintedBody {
+	hash := sha256.New()
+	io.WriteString(hash, target+"\n")
+
+	return &fingerprintedBody{ReadCloser: body, hash: hash}
+}
+
+func (b *fingerprintedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+
+	switch {
+	case err == io.EOF:
+		b.eof = true
+	case err != nil && b.err == nil:
+		b.err = err
+	}
+
+	return n, err
+}
+
+// drain reads what the handler left of the body.
+func (b *fingerprintedBody) drain() {
+	if !b.eof && b.err == nil {
+		_, _ = io.Copy(io.Discard, b)
+	}
+}
+
+// Sum returns the fingerprint of the request: its target and whole body.
+func (b *fingerprintedBody) Sum() (string, error) {
+	b.drain()
+
+	if b.err != nil {
+		return "", b.err
+	}
+
+	return hex.EncodeToString(b.hash.Sum(nil)), nil
+}
+
+// responseRecorder keeps a copy of the response body. The rest of the
+// request body is read before the response is written, as the server may
+// no longer read it afterwards.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	request *fingerprintedBody
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.request.drain()
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.request.drain()
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *responseRecorder) WriteHeaderNow() {
+	r.request.drain()
+	r.ResponseWriter.WriteHeaderNow()
+}
+
+// Unwrap lets http.ResponseController reach the connection, so handlers
+// behind Idempotent can still change their deadlines.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"music/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyKeys is a service.Idempotency keeping its keys in a map.
+type idempotencyKeys struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func (s *idempotencyKeys) ReserveIdempotencyKey(
+	ctx context.Context, key, fingerprint string,
+) (models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		return record, false, nil
+	}
+
+	s.records[key] = models.IdempotencyRecord{Fingerprint: fingerprint}
+
+	return models.IdempotencyRecord{}, true, nil
+}
+
+func (s *idempotencyKeys) CompleteIdempotencyKey(ctx context.Context, key string, record models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.Completed = true
+	s.records[key] = record
+
+	return nil
+}
+
+func (s *idempotencyKeys) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
+
+// newIdempotentRouter serves POST /songs behind Idempotent with handler,
+// recovering its panics.
+func newIdempotentRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	c := newIdempotencyController(&idempotencyKeys{records: make(map[string]models.IdempotencyRecord)},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(ctx *gin.Context, _ any) {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.POST("/songs", c.Idempotent, handler)
+
+	return router
+}
+
+func postIdempotent(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/songs", strings.NewReader(body))
+	req.Header.Set(idempotencyKeyHeader, key)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	calls := 0
+	router := newIdempotentRouter(func(ctx *gin.Context) {
+		calls++
+		// Half of the body is read, the rest is still part of the fingerprint.
+		buf := make([]byte, 4)
+		_, _ = io.ReadFull(ctx.Request.Body, buf)
+		ctx.String(http.StatusCreated, "created %s", buf)
+	})
+
+	first := postIdempotent(router, "key", "songsong")
+	if first.Code != http.StatusCreated || first.Body.String() != "created song" {
+		t.Fatalf("first request = %d %q", first.Code, first.Body)
+	}
+
+	retry := postIdempotent(router, "key", "songsong")
+	if retry.Code != http.StatusCreated || retry.Body.String() != "created song" ||
+		retry.Header().Get(idempotentReplayedHeader) != "true" || calls != 1 {
+		t.Errorf("retry = %d %q after %d calls, want the replayed response", retry.Code, retry.Body, calls)
+	}
+
+	if other := postIdempotent(router, "key", "songsing"); other.Code != http.StatusUnprocessableEntity {
+		t.Errorf("request with another body = %d, want %d", other.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotentReleasesKeyOnPanic(t *testing.T) {
+	panics := true
+	router := newIdempotentRouter(func(ctx *gin.Context) {
+		if panics {
+			panic("handler failed")
+		}
+		ctx.Status(http.StatusCreated)
+	})
+
+	if w := postIdempotent(router, "key", "song"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking request = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+
+	panics = false
+
+	if w := postIdempotent(router, "key", "song"); w.Code != http.StatusCreated || w.Header().Get(idempotentReplayedHeader) != "" {
+		t.Errorf("retry after a panic = %d, replayed %q, want the request to run again",
+			w.Code, w.Header().Get(idempotentReplayedHeader))
+	}
+}
+
+func TestIdempotentKeyInProgress(t *testing.T) {
+	var (
+		router   *gin.Engine
+		inFlight *httptest.ResponseRecorder
+	)
+
+	router = newIdempotentRouter(func(ctx *gin.Context) {
+		// The same request again while this one is still running.
+		inFlight = postIdempotent(router, "key", "song")
+		ctx.Status(http.StatusCreated)
+	})
+
+	if w := postIdempotent(router, "key", "song"); w.Code != http.StatusCreated {
+		t.Fatalf("first request = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	if inFlight.Code != http.StatusConflict {
+		t.Errorf("request while the key is in progress = %d, want %d", inFlight.Code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"log/slog"
+	"music/internal/models"
+	"music/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Import interface {
+	ImportMusics(ctx *gin.Context)
+}
+
+type importController struct {
+	service service.Import
+	logger  *slog.Logger
+}
+
+func newImportController(service service.Import, logger *slog.Logger) *importController {
+	return &importController{service: service, logger: logger}
+}
+
+// importFormats maps the accepted content types to the import formats.
+var importFormats = map[string]models.ImportFormat{
+	"application/x-ndjson": models.ImportNDJSON,
+	"application/ndjson":   models.ImportNDJSON,
+	"application/jsonl":    models.ImportNDJSON,
+	"text/csv":             models.ImportCSV,
+}
+
+// @Summary	Import songs
+// @Description	Imports a catalog of songs given as NDJSON or CSV with a header row.
+// @Description	Each row has group and song, and optionally release_date, text and link.
+// @Description	Rows with any of those are stored as they are, the others are enriched in
+// @Description	the background. Songs already stored with the same group and title are skipped.
+// @Tags		import
+// @Accept		application/x-ndjson
+// @Accept		text/csv
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		format	query		string	false	"Overrides the Content-Type"	Enums(ndjson, csv)
+// @Success	200		{object}	models.ImportReport
+// @Failure	400		{object}	ErrorResponse
+// @Failure	401		{object}	ErrorResponse
+// @Failure	403		{object}	ErrorResponse
+// @Failure	415		{object}	ErrorResponse
+// @Failure	422		{object}	ErrorResponse
+// @Failure	429		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/import [post]
+func (c *importController) ImportMusics(ctx *gin.Context) {
+	format := models.ImportFormat(ctx.Query("format"))
+	if format == "" {
+		format = importFormats[ctx.ContentType()]
+	}
+
+	if format != models.ImportNDJSON && format != models.ImportCSV {
+		c.logger.DebugContext(ctx, "Unsupported import format", slog.String("content_type", ctx.ContentType()))
+		abortWithError(ctx, http.StatusUnsupportedMediaType, codeUnsupportedMediaType,
+			"Expected an application/x-ndjson or text/csv body")
+		return
+	}
+
+	// A large catalog takes longer to upload and store than the read and
+	// write timeouts of the server.
+	rc := http.NewResponseController(ctx.Writer)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	report, err := c.service.ImportMusics(ctx, ctx.Request.Body, format)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to import musics", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"music/internal/logging"
+	"music/internal/models"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Logging interface {
+	// Logged is a middleware that puts a logger into the request context
+	// with the ID, method and route of the request, see
+	// logging.LoggerFrom, and logs a line per request with its status,
+	// latency and size once it is handled, whatever the level of the
+	// logger. It runs after Audited, which gives the request its ID.
+	Logged(ctx *gin.Context)
+	// Recovered is a middleware that turns a panic of a handler into a 500
+	// and logs it with its stack trace.
+	Recovered(ctx *gin.Context)
+}
+
+type loggingController struct {
+	logger *slog.Logger
+}
+
+func newLoggingController(logger *slog.Logger) *loggingController {
+	return &loggingController{logger: logger}
+}
+
+func (c *loggingController) Logged(ctx *gin.Context) {
+	start := time.Now()
+
+	ctx.Request = ctx.Request.WithContext(logging.WithLogger(ctx.Request.Context(), c.logger,
+		slog.String("request_id", models.AuditFrom(ctx).RequestID),
+		slog.String("method", ctx.Request.Method),
+		slog.String("route", ctx.FullPath()),
+	))
+
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+
+	level := slog.LevelInfo
+	switch {
+	case status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+
+	record := slog.NewRecord(time.Now(), level, "Request handled", 0)
+	record.AddAttrs(
+		slog.String("path", ctx.Request.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("latency", time.Since(start)),
+		slog.Int("bytes", max(ctx.Writer.Size(), 0)),
+		slog.String("client_ip", ctx.ClientIP()),
+	)
+
+	// The record goes to the handler as is, past the level of the logger,
+	// so the access log is complete in prod too. Handlers down the chain
+	// add to the context, such as the principal.
+	if err := c.logger.Handler().Handle(ctx.Request.Context(), record); err != nil {
+		c.logger.ErrorContext(ctx, "Failed to log request", slog.String("error", err.Error()))
+	}
+}
+
+func (c *loggingController) Recovered(ctx *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		err, ok := recovered.(error)
+		if !ok {
+			err = fmt.Errorf("%v", recovered)
+		}
+
+		// A client that went away is no bug of the handler.
+		if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+			c.logger.WarnContext(ctx, "Connection lost", slog.String("error", err.Error()))
+			ctx.Abort()
+			return
+		}
+
+		c.logger.ErrorContext(ctx, "Panic recovered",
+			slog.String("error", err.Error()),
+			slog.String("stack", string(debug.Stack())),
+		)
+
+		if ctx.Writer.Written() {
+			ctx.Abort()
+			return
+		}
+
+		abortWithError(ctx, http.StatusInternalServerError, codeInternal, "Internal Server Error")
+	}()
+
+	ctx.Next()
+}
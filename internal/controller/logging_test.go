@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"music/internal/logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoggedWritesAccessLineAtAnyLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		status int
+		level  string
+	}{
+		{http.StatusOK, "INFO"},
+		{http.StatusNotFound, "WARN"},
+		{http.StatusInternalServerError, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var out bytes.Buffer
+
+			// Stricter than the prod logger, which keeps WARN and above.
+			logger := slog.New(logging.Handler(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelError + 1})))
+
+			router := gin.New()
+			router.Use(newLoggingController(logger).Logged)
+			router.GET("/songs/:id", func(ctx *gin.Context) {
+				ctx.Status(tt.status)
+			})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/songs/1", nil))
+
+			var line struct {
+				Level  string `json:"level"`
+				Msg    string `json:"msg"`
+				Status int    `json:"status"`
+				Route  string `json:"route"`
+			}
+
+			if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+				t.Fatalf("access line %q: %v", out.String(), err)
+			}
+
+			if line.Msg != "Request handled" || line.Level != tt.level || line.Status != tt.status ||
+				line.Route != "/songs/:id" {
+				t.Errorf("access line = %+v, want %s with status %d of /songs/:id", line, tt.level, tt.status)
+			}
+		})
+	}
+}
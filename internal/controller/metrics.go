@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"music/internal/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels the requests that match no route, so that scans of
+// random paths do not make new series.
+const unmatchedRoute = "unmatched"
+
+type Metrics interface {
+	// Metered is a middleware that counts the requests and times them by
+	// method, route pattern and status.
+	Metered(ctx *gin.Context)
+}
+
+type metricsController struct {
+	metrics *metrics.Metrics
+}
+
+func newMetricsController(m *metrics.Metrics) *metricsController {
+	return &metricsController{metrics: m}
+}
+
+func (c *metricsController) Metered(ctx *gin.Context) {
+	start := time.Now()
+
+	ctx.Next()
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	c.metrics.ObserveHTTPRequest(ctx.Request.Method, route, ctx.Writer.Status(), time.Since(start))
+}
@@ -12,12 +12,23 @@ import (
 
 type Music interface {
 	GetMusics(ctx *gin.Context)
+	GetMusic(ctx *gin.Context)
 	GetSongLyricsByVerses(ctx *gin.Context)
 	UpdateMusic(ctx *gin.Context)
+	ReplaceMusic(ctx *gin.Context)
 	DeleteMusic(ctx *gin.Context)
+	GetDeletedMusics(ctx *gin.Context)
+	RestoreMusic(ctx *gin.Context)
+	GetMusicHistory(ctx *gin.Context)
+	RevertMusic(ctx *gin.Context)
 	AddMusic(ctx *gin.Context)
+	GetEnrichmentStatus(ctx *gin.Context)
+	SearchMusics(ctx *gin.Context)
 }
 
+// mergePatchContentType is the media type of JSON Merge Patch documents.
+const mergePatchContentType = "application/merge-patch+json"
+
 type musicController struct {
 	service service.Music
 	logger  *slog.Logger
@@ -28,49 +39,125 @@ func newMusicController(service service.Music, logger *slog.Logger) *musicContro
 }
 
 // @Summary	Get musics
+// @Description	Without cursor returns a plain array paginated by offset. With cursor,
+// @Description	even empty for the first page, returns a models.MusicPage envelope whose
+// @Description	next_cursor is passed back to fetch the following page.
 // @Tags		music
 // @Accept		json
 // @Produce	json
 // @Param		group			query		string	false	"Group"
+// @Param		group_match		query		string	false	"Group match mode"	Enums(contains, exact)
 // @Param		song			query		string	false	"Song name"
-// @Param		release_date	query		string	false	"Release date"
+// @Param		song_match		query		string	false	"Song match mode"	Enums(contains, exact)
 // @Param		text			query		string	false	"Text"
+// @Param		text_match		query		string	false	"Text match mode"	Enums(contains, exact)
+// @Param		release_date	query		string	false	"Release date, YYYY-MM-DD"
+// @Param		released_after	query		string	false	"Released on or after, YYYY-MM-DD"
+// @Param		released_before	query		string	false	"Released on or before, YYYY-MM-DD"
+// @Param		sort			query		string	false	"Comma separated group, song, release_date or id, each with optional :asc or :desc"
 // @Param		offset			query		int		false	"offset"
 // @Param		limit			query		int		false	"limit"
-// @Success	200				{string}	Success
-// @Failure	400				{string}	Bad			request
-// @Failure	500				{string}	Internal	Server	Error
+// @Param		cursor			query		string	false	"Cursor from next_cursor, empty for the first page"
+// @Success	200				{array}		models.MusicInfo
+// @Failure	400				{object}	ErrorResponse
+// @Failure	422				{object}	ErrorResponse
+// @Failure	429				{object}	ErrorResponse
+// @Failure	500				{object}	ErrorResponse
 // @Router		/ [get]
 func (c *musicController) GetMusics(ctx *gin.Context) {
-	group := ctx.Query("group")
-	song := ctx.Query("song")
-	releaseDate := ctx.Query("release_date")
-	text := ctx.Query("text")
+	limit, offset, ok := parsePagination(ctx, c.logger)
+	if !ok {
+		return
+	}
 
-	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
-	if err != nil || offset < 0 {
-		c.logger.DebugContext(ctx, "Invalid offset", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+	filter, ok := parseMusicFilter(ctx, c.logger)
+	if !ok {
 		return
 	}
 
-	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
-	if err != nil || limit < 1 {
-		c.logger.DebugContext(ctx, "Invalid limit", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+	filter.Limit, filter.Offset = limit, offset
+
+	// The cursor parameter, even empty, switches to keyset pagination and
+	// the page envelope. Without it the plain offset listing is kept.
+	if cursor, ok := ctx.GetQuery("cursor"); ok {
+		page, err := c.service.GetMusicsPage(ctx, filter, cursor)
+		if err != nil {
+			abortWithServiceError(ctx, c.logger, "Failed to get musics", err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, page)
 		return
 	}
 
-	musics, err := c.service.GetMusics(ctx, group, song, releaseDate, text, limit, offset)
+	musics, err := c.service.GetMusics(ctx, filter)
 	if err != nil {
-		c.logger.ErrorContext(ctx, "Failed to get musics", slog.String("error", err.Error()))
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+		abortWithServiceError(ctx, c.logger, "Failed to get musics", err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, musics)
 }
 
+// @Summary	Get music
+// @Description	Returns the song with an ETag, a matching If-None-Match gets 304.
+// @Description	Deprecated: with couplet or size the lyrics are returned as by /{music_id}/lyrics.
+// @Tags		music
+// @Accept		json
+// @Produce	json
+// @Param		music_id		path		int		true	"music ID int"
+// @Param		If-None-Match	header		string	false	"ETag of a cached copy"
+// @Success	200				{object}	models.MusicInfo
+// @Header		200				{string}	ETag	"Entity tag of the song"
+// @Success	304				{string}	Success
+// @Failure	400				{object}	ErrorResponse
+// @Failure	404				{object}	ErrorResponse
+// @Failure	429				{object}	ErrorResponse
+// @Failure	500				{object}	ErrorResponse
+// @Router		/{music_id} [get]
+func (c *musicController) GetMusic(ctx *gin.Context) {
+	// Before the lyrics got their own route they were served here.
+	if query := ctx.Request.URL.Query(); query.Has("couplet") || query.Has("size") {
+		c.getLegacyLyrics(ctx)
+		return
+	}
+
+	ID, ok := parseIDParam(ctx, c.logger, "music_id")
+	if !ok {
+		return
+	}
+
+	music, err := c.service.GetMusic(ctx, ID)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to get music", err)
+		return
+	}
+
+	etag := musicETag(music)
+	ctx.Header("ETag", etag)
+
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, music)
+}
+
+// getLegacyLyrics serves the verses as a plain array from the song URL and
+// points clients to the lyrics route.
+func (c *musicController) getLegacyLyrics(ctx *gin.Context) {
+	ctx.Header("Deprecation", "true")
+	ctx.Header("Link", "</"+ctx.Param("music_id")+`/lyrics>; rel="successor-version"`)
+
+	lyrics, ok := c.getLyrics(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lyrics.Verses)
+}
+
 // @Summary	Get lyrics
 // @Tags		music
 // @Accept		json
@@ -78,126 +165,458 @@ func (c *musicController) GetMusics(ctx *gin.Context) {
 // @Param		music_id	path		int	true	"music ID int"
 // @Param		couplet		query		int	false	"couplet"
 // @Param		size		query		int	false	"size"
-// @Success	200			{string}	Success
-// @Failure	400			{string}	Bad			request
-// @Failure	500			{string}	Internal	Server	Error
-// @Router		/{music_id} [get]
+// @Success	200			{object}	models.Lyrics
+// @Failure	400			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	416			{object}	ErrorResponse
+// @Failure	422			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/{music_id}/lyrics [get]
 func (c *musicController) GetSongLyricsByVerses(ctx *gin.Context) {
+	lyrics, ok := c.getLyrics(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lyrics)
+}
+
+func (c *musicController) getLyrics(ctx *gin.Context) (models.Lyrics, bool) {
 	couplet, err := strconv.Atoi(ctx.DefaultQuery("couplet", "1"))
 	if err != nil {
-		c.logger.DebugContext(ctx, "Invalid query param page", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid query param page"})
-		return
+		c.logger.DebugContext(ctx, "Invalid query param couplet", slog.String("error", err.Error()))
+		abortWithBadRequest(ctx, "Invalid query param couplet")
+		return models.Lyrics{}, false
 	}
 
 	size, err := strconv.Atoi(ctx.DefaultQuery("size", "1"))
 	if err != nil {
 		c.logger.DebugContext(ctx, "Invalid query param size", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid query param size"})
-		return
+		abortWithBadRequest(ctx, "Invalid query param size")
+		return models.Lyrics{}, false
 	}
-	musicID, err := strconv.Atoi(ctx.Param("music_id"))
-	if err != nil {
-		c.logger.DebugContext(ctx, "Invalid ID param", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid ID param"})
-		return
+
+	musicID, ok := parseIDParam(ctx, c.logger, "music_id")
+	if !ok {
+		return models.Lyrics{}, false
 	}
 
-	musicText, err := c.service.GetSongLyricsByVerses(ctx, musicID, couplet, size)
+	lyrics, err := c.service.GetSongLyricsByVerses(ctx, musicID, couplet, size)
 	if err != nil {
-		c.logger.ErrorContext(ctx, "Failed to get music text", slog.String("error", err.Error()))
-		ctx.AbortWithStatus(http.StatusInternalServerError)
-		return
+		abortWithServiceError(ctx, c.logger, "Failed to get music text", err)
+		return models.Lyrics{}, false
 	}
 
-	ctx.JSON(http.StatusOK, musicText)
+	return lyrics, true
 }
 
 // @Summary	Updte musics
+// @Description	Applies a JSON Merge Patch (RFC 7396): members left out are kept, null clears
+// @Description	release_date, text or link. Only group, song, release_date, text and link can be
+// @Description	updated, other members fail with 422. group and song cannot be cleared, release_date
+// @Description	is YYYY-MM-DD and link an http or https URL. With If-Match the update only applies
+// @Description	while the song has that ETag, else 412.
 // @Tags		music
+// @Accept		application/merge-patch+json
 // @Accept		json
 // @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
 // @Param		music_id	path		int					true	"music ID"
-// @Param		request		body		models.MusicUpdate	true	"body json"
-// @Success	202			{string}	Success
-// @Failure	400			{string}	Bad			request
-// @Failure	500			{string}	Internal	Server	Error
+// @Param		If-Match	header		string				false	"ETag the song must have"
+// @Param		request		body		models.MusicUpdate	true	"merge patch"
+// @Success	200			{object}	models.MusicInfo
+// @Header		200			{string}	ETag	"Entity tag of the updated song"
+// @Failure	400			{object}	ErrorResponse
+// @Failure	401			{object}	ErrorResponse
+// @Failure	403			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	409			{object}	ErrorResponse
+// @Failure	412			{object}	ErrorResponse
+// @Failure	415			{object}	ErrorResponse
+// @Failure	422			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
 // @Router		/{music_id} [patch]
 func (c *musicController) UpdateMusic(ctx *gin.Context) {
 	ID, err := strconv.Atoi(ctx.Param("music_id"))
 	if err != nil {
 		c.logger.DebugContext(ctx, "Failed to conver str to int", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Inalid ID param"})
+		abortWithBadRequest(ctx, "Invalid ID param")
+		return
+	}
+
+	if contentType := ctx.ContentType(); contentType != mergePatchContentType && contentType != gin.MIMEJSON {
+		c.logger.DebugContext(ctx, "Unsupported patch format", slog.String("content_type", contentType))
+		abortWithError(ctx, http.StatusUnsupportedMediaType, codeUnsupportedMediaType,
+			"Expected an application/merge-patch+json body")
+		return
+	}
+
+	version, ok := parseIfMatch(ctx, c.logger)
+	if !ok {
 		return
 	}
 
 	var updates models.MusicUpdate
 
 	if err := ctx.ShouldBindJSON(&updates); err != nil {
-		c.logger.DebugContext(ctx, "Error on parsing body", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithBindError(ctx, c.logger, err)
 		return
 	}
 
-	if err := c.service.UpdateMusic(ctx, ID, updates); err != nil {
-		c.logger.ErrorContext(ctx, "Failed to update music", slog.String("error", err.Error()))
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+	c.updateMusic(ctx, ID, updates, version)
+}
+
+// @Summary	Replace music
+// @Description	Replaces every field of the song: group and song are required, release_date, text
+// @Description	and link left out are cleared. The values are checked as for PATCH. With If-Match
+// @Description	the song is only replaced while it has that ETag, else 412.
+// @Tags		music
+// @Accept		json
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		music_id	path		int						true	"music ID"
+// @Param		If-Match	header		string					false	"ETag the song must have"
+// @Param		request		body		models.MusicReplacement	true	"body json"
+// @Success	200			{object}	models.MusicInfo
+// @Header		200			{string}	ETag	"Entity tag of the updated song"
+// @Failure	400			{object}	ErrorResponse
+// @Failure	401			{object}	ErrorResponse
+// @Failure	403			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	409			{object}	ErrorResponse
+// @Failure	412			{object}	ErrorResponse
+// @Failure	422			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/{music_id} [put]
+func (c *musicController) ReplaceMusic(ctx *gin.Context) {
+	ID, ok := parseIDParam(ctx, c.logger, "music_id")
+	if !ok {
+		return
+	}
+
+	version, ok := parseIfMatch(ctx, c.logger)
+	if !ok {
 		return
 	}
 
-	ctx.Status(http.StatusAccepted)
+	var replacement models.MusicReplacement
+
+	if err := ctx.ShouldBindJSON(&replacement); err != nil {
+		abortWithBindError(ctx, c.logger, err)
+		return
+	}
+
+	c.updateMusic(ctx, ID, replacement.Update(), version)
+}
+
+func (c *musicController) updateMusic(ctx *gin.Context, ID int, updates models.MusicUpdate, version int) {
+	music, err := c.service.UpdateMusic(ctx, ID, updates, version)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to update music", err)
+		return
+	}
+
+	ctx.Header("ETag", musicETag(music))
+	ctx.JSON(http.StatusOK, music)
 }
 
 // @Summary	Delete music
+// @Description	Moves the song to the trash, from where it can be restored until it is purged.
+// @Description	With If-Match the song is only deleted while it has that ETag, else 412.
 // @Tags		music
 // @Accept		json
 // @Produce	json
-// @Param		music_id	path		int	true	"music ID int"
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		music_id	path		int		true	"music ID int"
+// @Param		If-Match	header		string	false	"ETag the song must have"
 // @Success	204			{string}	Success
-// @Failure	400			{string}	Bad			request
-// @Failure	500			{string}	Internal	Server	Error
+// @Failure	400			{object}	ErrorResponse
+// @Failure	401			{object}	ErrorResponse
+// @Failure	403			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	412			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
 // @Router		/{music_id} [delete]
 func (c *musicController) DeleteMusic(ctx *gin.Context) {
 	musicIDstr := ctx.Param("music_id")
 	musicID, err := strconv.Atoi(musicIDstr)
 	if err != nil {
 		c.logger.DebugContext(ctx, "Error on get params", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "invalid music ID"})
+		abortWithBadRequest(ctx, "Invalid ID param")
+		return
+	}
+
+	version, ok := parseIfMatch(ctx, c.logger)
+	if !ok {
 		return
 	}
 
-	if err := c.service.DeleteMusic(ctx, musicID); err != nil {
-		c.logger.ErrorContext(ctx, "Failed to delete music", slog.String("error", err.Error()))
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+	if err := c.service.DeleteMusic(ctx, musicID, version); err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to delete music", err)
 		return
 	}
 
 	ctx.Status(http.StatusNoContent)
 }
 
+// @Summary	Get deleted musics
+// @Description	Lists the trash, the latest deleted songs first.
+// @Tags		music
+// @Accept		json
+// @Produce	json
+// @Param		offset	query		int	false	"offset"
+// @Param		limit	query		int	false	"limit"
+// @Success	200		{array}		models.DeletedMusic
+// @Failure	400		{object}	ErrorResponse
+// @Failure	429		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/trash [get]
+func (c *musicController) GetDeletedMusics(ctx *gin.Context) {
+	limit, offset, ok := parsePagination(ctx, c.logger)
+	if !ok {
+		return
+	}
+
+	musics, err := c.service.GetDeletedMusics(ctx, limit, offset)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to get deleted musics", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, musics)
+}
+
+// @Summary	Restore music
+// @Description	Takes the song out of the trash. Fails with 409 when a song with the same title
+// @Description	was added since.
+// @Tags		music
+// @Accept		json
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		music_id	path		int	true	"music ID int"
+// @Success	200			{object}	models.MusicInfo
+// @Header		200			{string}	ETag	"Entity tag of the song"
+// @Failure	400			{object}	ErrorResponse
+// @Failure	401			{object}	ErrorResponse
+// @Failure	403			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	409			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/{music_id}/restore [post]
+func (c *musicController) RestoreMusic(ctx *gin.Context) {
+	ID, ok := parseIDParam(ctx, c.logger, "music_id")
+	if !ok {
+		return
+	}
+
+	music, err := c.service.RestoreMusic(ctx, ID)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to restore music", err)
+		return
+	}
+
+	ctx.Header("ETag", musicETag(music))
+	ctx.JSON(http.StatusOK, music)
+}
+
+// @Summary	Get music history
+// @Description	Lists the revisions of the song, the latest first, with the song before and after
+// @Description	each change, who made it and the ID of the request. rev is the version the change
+// @Description	brought the song to. Songs in the trash have a history too, and so do purged
+// @Description	songs, ending with a purge revision.
+// @Tags		music
+// @Accept		json
+// @Produce	json
+// @Param		music_id	path		int	true	"music ID int"
+// @Param		offset		query		int	false	"offset"
+// @Param		limit		query		int	false	"limit"
+// @Success	200			{array}		models.Revision
+// @Failure	400			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/{music_id}/history [get]
+func (c *musicController) GetMusicHistory(ctx *gin.Context) {
+	ID, ok := parseIDParam(ctx, c.logger, "music_id")
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePagination(ctx, c.logger)
+	if !ok {
+		return
+	}
+
+	history, err := c.service.GetMusicHistory(ctx, ID, limit, offset)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to get music history", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+// @Summary	Revert music
+// @Description	Brings the song back to how it was after revision rev, recorded as a new revision.
+// @Description	The enrichment status is left as is. Fails with 422 for a revision that deleted
+// @Description	the song and with 409 when another song has taken its title since.
+// @Tags		music
+// @Accept		json
+// @Produce	json
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		music_id	path		int		true	"music ID int"
+// @Param		rev			path		int		true	"revision to revert to"
+// @Param		If-Match	header		string	false	"ETag the song must have"
+// @Success	200			{object}	models.MusicInfo
+// @Header		200			{string}	ETag	"Entity tag of the song"
+// @Failure	400			{object}	ErrorResponse
+// @Failure	401			{object}	ErrorResponse
+// @Failure	403			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	409			{object}	ErrorResponse
+// @Failure	412			{object}	ErrorResponse
+// @Failure	422			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/{music_id}/revert/{rev} [post]
+func (c *musicController) RevertMusic(ctx *gin.Context) {
+	ID, ok := parseIDParam(ctx, c.logger, "music_id")
+	if !ok {
+		return
+	}
+
+	rev, ok := parseIDParam(ctx, c.logger, "rev")
+	if !ok {
+		return
+	}
+
+	version, ok := parseIfMatch(ctx, c.logger)
+	if !ok {
+		return
+	}
+
+	music, err := c.service.RevertMusic(ctx, ID, rev, version)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to revert music", err)
+		return
+	}
+
+	ctx.Header("ETag", musicETag(music))
+	ctx.JSON(http.StatusOK, music)
+}
+
 // @Summary	Add music
+// @Description	Returns the stored song, still pending enrichment, with its URL in the Location header.
+// @Description	Fails with 409 and the ID of the existing song when the group already has a
+// @Description	song with the same title, ignoring case. A retry with the same Idempotency-Key
+// @Description	gets the response of the first request with the Idempotent-Replayed header.
 // @Tags		music
 // @Accept		json
 // @Produce	json
-// @Param		request	body		models.Music	true	"body json"
-// @Success	201		{string}	Success
-// @Failure	400		{string}	Bad			request
-// @Failure	500		{string}	Internal	Server	Error
+// @Security	ApiKeyAuth
+// @Security	BearerAuth
+// @Param		Idempotency-Key	header		string			false	"Key to retry the request safely"
+// @Param		request			body		models.Music	true	"body json"
+// @Success	201				{object}	models.MusicInfo
+// @Header		201				{string}	Location	"URL of the song"
+// @Failure	400				{object}	ErrorResponse
+// @Failure	401				{object}	ErrorResponse
+// @Failure	403				{object}	ErrorResponse
+// @Failure	409				{object}	ErrorResponse
+// @Failure	422				{object}	ErrorResponse
+// @Failure	429				{object}	ErrorResponse
+// @Failure	500				{object}	ErrorResponse
 // @Router		/ [post]
 func (c *musicController) AddMusic(ctx *gin.Context) {
 	var music models.Music
 
 	if err := ctx.ShouldBindJSON(&music); err != nil {
 		c.logger.DebugContext(ctx, "Error on parse body params", slog.String("error", err.Error()))
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		abortWithBadRequest(ctx, "Invalid input: "+err.Error())
 		return
 	}
 
-	if err := c.service.AddMusic(ctx, music); err != nil {
-		c.logger.ErrorContext(ctx, "Failed to add music", slog.String("error", err.Error()))
-		ctx.AbortWithStatus(http.StatusInternalServerError)
+	stored, err := c.service.AddMusic(ctx, music)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to add music", err)
+		return
+	}
+
+	ctx.Header("Location", "/"+strconv.Itoa(stored.ID))
+	ctx.Header("ETag", musicETag(stored))
+	ctx.JSON(http.StatusCreated, stored)
+}
+
+// @Summary	Get enrichment status
+// @Tags		music
+// @Accept		json
+// @Produce	json
+// @Param		music_id	path		int	true	"music ID int"
+// @Success	200			{object}	models.EnrichmentStatus
+// @Failure	400			{object}	ErrorResponse
+// @Failure	404			{object}	ErrorResponse
+// @Failure	429			{object}	ErrorResponse
+// @Failure	500			{object}	ErrorResponse
+// @Router		/{music_id}/enrichment [get]
+func (c *musicController) GetEnrichmentStatus(ctx *gin.Context) {
+	musicID, err := strconv.Atoi(ctx.Param("music_id"))
+	if err != nil {
+		c.logger.DebugContext(ctx, "Invalid ID param", slog.String("error", err.Error()))
+		abortWithBadRequest(ctx, "Invalid ID param")
+		return
+	}
+
+	status, err := c.service.GetEnrichmentStatus(ctx, musicID)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to get enrichment status", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// @Summary	Search musics
+// @Description	Full-text search over song titles, groups and lyrics, ordered by relevance.
+// @Description	The query supports quoted phrases, "or" and "-" to exclude words.
+// @Tags		music
+// @Accept		json
+// @Produce	json
+// @Param		q		query		string	true	"Search query"
+// @Param		offset	query		int		false	"offset"
+// @Param		limit	query		int		false	"limit"
+// @Success	200		{array}		models.SearchResult
+// @Failure	400		{object}	ErrorResponse
+// @Failure	422		{object}	ErrorResponse
+// @Failure	429		{object}	ErrorResponse
+// @Failure	500		{object}	ErrorResponse
+// @Router		/search [get]
+func (c *musicController) SearchMusics(ctx *gin.Context) {
+	limit, offset, ok := parsePagination(ctx, c.logger)
+	if !ok {
+		return
+	}
+
+	query := models.SearchQuery{
+		Query:  ctx.Query("q"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	results, err := c.service.SearchMusics(ctx, query)
+	if err != nil {
+		abortWithServiceError(ctx, c.logger, "Failed to search musics", err)
 		return
 	}
 
-	ctx.Status(http.StatusCreated)
+	ctx.JSON(http.StatusOK, results)
 }
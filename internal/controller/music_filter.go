@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"log/slog"
+	"music/internal/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sortableFields is the whitelist of the sort query parameter.
+var sortableFields = map[string]bool{
+	models.SortGroup:       true,
+	models.SortSong:        true,
+	models.SortReleaseDate: true,
+	models.SortID:          true,
+}
+
+// parseMusicFilter reads the filters and the sort of the song listing,
+// pagination is left to the caller. On invalid values it aborts the request
+// and returns ok == false.
+func parseMusicFilter(ctx *gin.Context, logger *slog.Logger) (filter models.MusicFilter, ok bool) {
+	textFilters := []struct {
+		name   string
+		filter *models.TextFilter
+	}{
+		{"group", &filter.Group},
+		{"song", &filter.Song},
+		{"text", &filter.Text},
+	}
+
+	for _, f := range textFilters {
+		f.filter.Value = ctx.Query(f.name)
+
+		mode := models.MatchMode(ctx.DefaultQuery(f.name+"_match", string(models.MatchContains)))
+		if mode != models.MatchContains && mode != models.MatchExact {
+			logger.DebugContext(ctx, "Invalid match mode", slog.String(f.name+"_match", string(mode)))
+			abortWithBadRequest(ctx, "Invalid "+f.name+"_match, expected contains or exact")
+			return filter, false
+		}
+
+		f.filter.Mode = mode
+	}
+
+	dateFilters := []struct {
+		name string
+		date *models.Date
+	}{
+		{"release_date", &filter.ReleaseDate},
+		{"released_after", &filter.ReleasedAfter},
+		{"released_before", &filter.ReleasedBefore},
+	}
+
+	for _, f := range dateFilters {
+		date, err := models.ParseDate(ctx.Query(f.name))
+		if err != nil {
+			logger.DebugContext(ctx, "Invalid date", slog.String(f.name, ctx.Query(f.name)))
+			abortWithBadRequest(ctx, "Invalid "+f.name+", expected YYYY-MM-DD or DD.MM.YYYY")
+			return filter, false
+		}
+
+		*f.date = date
+	}
+
+	filter.Sort, ok = parseSort(ctx, logger)
+
+	return filter, ok
+}
+
+// parseSort reads a comma separated list of field[:asc|:desc].
+func parseSort(ctx *gin.Context, logger *slog.Logger) ([]models.SortField, bool) {
+	value := ctx.Query("sort")
+	if value == "" {
+		return nil, true
+	}
+
+	var (
+		sort = []models.SortField{}
+		seen = map[string]bool{}
+	)
+
+	for _, item := range strings.Split(value, ",") {
+		name, direction, _ := strings.Cut(strings.TrimSpace(item), ":")
+
+		field := models.SortField{Field: name}
+
+		switch direction {
+		case "", "asc":
+		case "desc":
+			field.Desc = true
+		default:
+			logger.DebugContext(ctx, "Invalid sort direction", slog.String("sort", value))
+			abortWithBadRequest(ctx, "Invalid sort direction "+direction+", expected asc or desc")
+			return nil, false
+		}
+
+		if !sortableFields[name] || seen[name] {
+			logger.DebugContext(ctx, "Invalid sort field", slog.String("sort", value))
+			abortWithBadRequest(ctx, "Invalid sort field "+name)
+			return nil, false
+		}
+
+		seen[name] = true
+		sort = append(sort, field)
+	}
+
+	return sort, true
+}
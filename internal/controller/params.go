@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePagination reads the offset and limit query parameters. On invalid
+// values it aborts the request and returns ok == false.
+func parsePagination(ctx *gin.Context, logger *slog.Logger) (limit, offset int, ok bool) {
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		logger.DebugContext(ctx, "Invalid offset", slog.String("offset", ctx.Query("offset")))
+		abortWithBadRequest(ctx, "Invalid offset")
+		return 0, 0, false
+	}
+
+	limit, err = strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		logger.DebugContext(ctx, "Invalid limit", slog.String("limit", ctx.Query("limit")))
+		abortWithBadRequest(ctx, "Invalid limit")
+		return 0, 0, false
+	}
+
+	return limit, offset, true
+}
+
+// parseIDParam reads an integer path parameter. On invalid values it aborts
+// the request and returns ok == false.
+func parseIDParam(ctx *gin.Context, logger *slog.Logger, name string) (ID int, ok bool) {
+	ID, err := strconv.Atoi(ctx.Param(name))
+	if err != nil {
+		logger.DebugContext(ctx, "Invalid ID param", slog.String("error", err.Error()))
+		abortWithBadRequest(ctx, "Invalid ID param")
+		return 0, false
+	}
+
+	return ID, true
+}
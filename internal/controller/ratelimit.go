@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"log/slog"
+	"math"
+	"music/internal/models"
+	"music/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const codeRateLimited = "rate_limited"
+
+type RateLimit interface {
+	// RateLimited returns a middleware that limits the requests of a client
+	// to the routes of class. Clients are told apart by their API key or
+	// token subject, anonymous ones by their IP. The state of the bucket is
+	// sent in the RateLimit-* headers, a denied request gets 429 with
+	// Retry-After.
+	RateLimited(class string) gin.HandlerFunc
+}
+
+type rateLimitController struct {
+	service service.RateLimit
+	logger  *slog.Logger
+}
+
+func newRateLimitController(service service.RateLimit, logger *slog.Logger) *rateLimitController {
+	return &rateLimitController{service: service, logger: logger}
+}
+
+func (c *rateLimitController) RateLimited(class string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := c.service.TakeRateLimitToken(ctx, class, rateLimitClient(ctx))
+		if err != nil {
+			// The limiter is a protection, not a dependency: requests go on
+			// when its backend fails.
+			c.logger.ErrorContext(ctx, "Failed to check rate limit", slog.String("error", err.Error()))
+			ctx.Next()
+			return
+		}
+
+		if result.Limit.Enabled() {
+			ctx.Header("RateLimit-Policy", strconv.Itoa(result.Limit.Requests)+";w="+seconds(result.Limit.Per))
+			ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+			ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			ctx.Header("RateLimit-Reset", seconds(result.Reset))
+		}
+
+		if !result.Allowed {
+			c.logger.DebugContext(ctx, "Rate limited", slog.String("class", class))
+			ctx.Header("Retry-After", seconds(result.RetryAfter))
+			abortWithError(ctx, http.StatusTooManyRequests, codeRateLimited, "Too many requests, retry later")
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitClient returns whom the bucket of a request belongs to.
+func rateLimitClient(ctx *gin.Context) string {
+	if principal := models.PrincipalFrom(ctx); principal.Authenticated() {
+		return principal.Method + ":" + principal.Subject
+	}
+
+	return "ip:" + ctx.ClientIP()
+}
+
+// seconds formats d in whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"music/internal/models"
+	"music/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimits is a service.RateLimit over memory buckets with a clock of its
+// own. A set err fails every token.
+type rateLimits struct {
+	repo  repository.RateLimit
+	limit models.RateLimit
+	now   time.Time
+	err   error
+}
+
+func (s *rateLimits) TakeRateLimitToken(ctx context.Context, class, client string) (models.RateLimitResult, error) {
+	if s.err != nil {
+		return models.RateLimitResult{}, s.err
+	}
+
+	if !s.limit.Enabled() {
+		return models.RateLimitResult{Allowed: true}, nil
+	}
+
+	return s.repo.TakeRateLimitToken(ctx, class+":"+client, s.limit, s.now)
+}
+
+// newRateLimitRouter serves GET /songs behind the API key authentication
+// of newAuthRouter and the rate limit of limits.
+func newRateLimitRouter(limits *rateLimits) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	auth := newAuthController(apiKeys{
+		principals: map[string]models.Principal{
+			"reader": {Subject: "reader", Role: models.RoleReadOnly, Method: models.AuthAPIKey},
+		},
+		anonymous: models.RoleReadOnly,
+	}, logger)
+	c := newRateLimitController(limits, logger)
+
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(auth.Authenticated)
+	router.GET("/songs", c.RateLimited(models.RateLimitRead), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	return router
+}
+
+func TestRateLimited(t *testing.T) {
+	limits := &rateLimits{
+		repo:  repository.NewRateLimitMemory(),
+		limit: models.RateLimit{Requests: 2, Per: 10 * time.Second},
+		now:   time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	router := newRateLimitRouter(limits)
+
+	steps := []struct {
+		name       string
+		after      time.Duration
+		ip, key    string
+		want       int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{"first", 0, "192.0.2.1", "", http.StatusOK, "1", "5", ""},
+		{"second", 0, "192.0.2.1", "", http.StatusOK, "0", "10", ""},
+		{"exhausted", 0, "192.0.2.1", "", http.StatusTooManyRequests, "0", "10", "5"},
+		{"still exhausted", 4500 * time.Millisecond, "192.0.2.1", "", http.StatusTooManyRequests, "0", "6", "1"},
+		{"another IP", 0, "192.0.2.2", "", http.StatusOK, "1", "5", ""},
+		{"API key from the same IP", 0, "192.0.2.1", "reader", http.StatusOK, "1", "5", ""},
+		{"refilled", 500 * time.Millisecond, "192.0.2.1", "", http.StatusOK, "0", "10", ""},
+	}
+
+	for _, step := range steps {
+		limits.now = limits.now.Add(step.after)
+
+		req := httptest.NewRequest(http.MethodGet, "/songs", nil)
+		req.RemoteAddr = step.ip + ":1234"
+		if step.key != "" {
+			req.Header.Set(apiKeyHeader, step.key)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != step.want {
+			t.Errorf("%s: GET /songs = %d, want %d", step.name, w.Code, step.want)
+		}
+
+		header := w.Header()
+		for name, want := range map[string]string{
+			"RateLimit-Policy":    "2;w=10",
+			"RateLimit-Limit":     "2",
+			"RateLimit-Remaining": step.remaining,
+			"RateLimit-Reset":     step.reset,
+			"Retry-After":         step.retryAfter,
+		} {
+			if got := header.Get(name); got != want {
+				t.Errorf("%s: %s = %q, want %q", step.name, name, got, want)
+			}
+		}
+	}
+}
+
+func TestRateLimitedWithoutLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits *rateLimits
+	}{
+		{"no limit", &rateLimits{}},
+		{"failing backend", &rateLimits{err: errors.New("connection refused")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRateLimitRouter(tt.limits)
+
+			for range 3 {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+				if w.Code != http.StatusOK {
+					t.Fatalf("GET /songs = %d, want %d", w.Code, http.StatusOK)
+				}
+
+				if got := w.Header().Get("RateLimit-Limit"); got != "" {
+					t.Errorf("RateLimit-Limit = %q, want none", got)
+				}
+			}
+		})
+	}
+}
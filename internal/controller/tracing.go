@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"music/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "music/internal/controller"
+
+type Tracing interface {
+	// Traced is a middleware that runs the request in a server span named
+	// after its route pattern, continuing the trace of a traceparent header.
+	// It comes first so that the logs of every later handler carry the trace.
+	Traced(ctx *gin.Context)
+}
+
+type tracingController struct {
+	tracer trace.Tracer
+}
+
+func newTracingController() *tracingController {
+	return &tracingController{tracer: otel.Tracer(tracerName)}
+}
+
+func (c *tracingController) Traced(ctx *gin.Context) {
+	reqCtx := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+	name := ctx.Request.Method
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+		semconv.URLPath(ctx.Request.URL.Path),
+		semconv.ClientAddress(ctx.ClientIP()),
+		semconv.UserAgentOriginal(ctx.Request.UserAgent()),
+	}
+
+	// Unmatched requests are named by their method only, as paths would make
+	// a name per scan.
+	if route := ctx.FullPath(); route != "" {
+		name += " " + route
+		attrs = append(attrs, semconv.HTTPRoute(route))
+	}
+
+	reqCtx, span := c.tracer.Start(reqCtx, name,
+		trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	ctx.Request = ctx.Request.WithContext(reqCtx)
+
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+
+	span.SetAttributes(
+		semconv.HTTPResponseStatusCode(status),
+		attribute.String("music.request_id", models.AuditFrom(ctx).RequestID),
+	)
+
+	if principal := models.PrincipalFrom(ctx); principal.Authenticated() {
+		span.SetAttributes(semconv.EnduserID(principal.Subject))
+	}
+
+	// Client errors are the client's, only server errors fail the span.
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
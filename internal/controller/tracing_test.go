@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"music/internal/tracing/tracingtest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	remoteTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	remoteSpan  = "00f067aa0ba902b7"
+)
+
+// serveTraced serves a request to GET /songs/:id behind Traced and returns
+// the span it ended in.
+func serveTraced(t *testing.T, exporter *tracetest.InMemoryExporter, status int, header http.Header) tracetest.SpanStub {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(newTracingController().Traced)
+	router.GET("/songs/:id", func(ctx *gin.Context) {
+		ctx.Status(status)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/songs/1", nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	return spans[0]
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func TestTracedServerSpan(t *testing.T) {
+	exporter := tracingtest.Record(t)
+
+	span := serveTraced(t, exporter, http.StatusOK, http.Header{"Traceparent": {traceparent}})
+
+	if span.Name != "GET /songs/:id" || span.SpanKind != trace.SpanKindServer {
+		t.Errorf("span is %s %q, want a server span named after the route", span.SpanKind, span.Name)
+	}
+
+	if span.SpanContext.TraceID().String() != remoteTrace || span.Parent.SpanID().String() != remoteSpan ||
+		!span.Parent.IsRemote() {
+		t.Errorf("span is in trace %s with parent %s, want the trace of the traceparent header",
+			span.SpanContext.TraceID(), span.Parent.SpanID())
+	}
+
+	if route := spanAttribute(span, semconv.HTTPRouteKey).AsString(); route != "/songs/:id" {
+		t.Errorf("http.route = %q, want /songs/:id", route)
+	}
+
+	if status := spanAttribute(span, semconv.HTTPResponseStatusCodeKey).AsInt64(); status != http.StatusOK {
+		t.Errorf("http.response.status_code = %d, want %d", status, http.StatusOK)
+	}
+
+	if span.Status.Code == codes.Error {
+		t.Errorf("span status = %v, want it unset", span.Status)
+	}
+}
+
+func TestTracedServerErrorFailsSpan(t *testing.T) {
+	exporter := tracingtest.Record(t)
+
+	if span := serveTraced(t, exporter, http.StatusNotFound, nil); span.Status.Code == codes.Error || span.Parent.IsValid() {
+		t.Errorf("404 span has status %v and parent %s, want a root span that did not fail", span.Status, span.Parent.SpanID())
+	}
+
+	exporter.Reset()
+
+	if span := serveTraced(t, exporter, http.StatusInternalServerError, nil); span.Status.Code != codes.Error {
+		t.Errorf("500 span status = %v, want an error", span.Status)
+	}
+}
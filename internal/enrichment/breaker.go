@@ -0,0 +1,98 @@
+package enrichment
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// breaker is a consecutive-failures circuit breaker. After threshold failures
+// in a row it rejects calls for cooldown, then lets a single probe through:
+// a successful probe closes the circuit, a failed one opens it again.
+type breaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openUntil time.Time
+
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a call may be made right now.
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if b.now().Before(b.openUntil) {
+			return false
+		}
+
+		b.state = stateHalfOpen
+		return true
+	case stateHalfOpen:
+		// A probe is already in flight.
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = stateClosed
+	b.failures = 0
+}
+
+// abandon reports a call that ended without telling whether the API is up,
+// such as one canceled by its caller. An abandoned probe lets the next call
+// probe again.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == stateHalfOpen {
+		b.state = stateOpen
+		b.openUntil = b.now()
+	}
+}
+
+func (b *breaker) failure() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.state = stateOpen
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
@@ -0,0 +1,228 @@
+// Package enrichment is the client of the external song info API that fills
+// in release dates, lyrics and links for new songs.
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"music/internal/config"
+	"music/internal/metrics"
+	"music/internal/tracing"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "music/internal/enrichment"
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+var errInvalidResponse = errors.New("failed to decode response")
+
+// escapedLineBreaks turns the literal \n escapes the API sends in lyrics
+// instead of newlines into real ones.
+var escapedLineBreaks = strings.NewReplacer(`\r\n`, "\n", `\n`, "\n")
+
+// StatusError is returned when the API answers with an unexpected status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned status: %d", e.StatusCode)
+}
+
+// Info is the song metadata returned by the API. Text has real line breaks.
+type Info struct {
+	RelaseDate string `json:"relaseDate"`
+	Text       string `json:"text"`
+	Link       string `json:"link"`
+}
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	logger     *slog.Logger
+	metrics    *metrics.Metrics
+	tracer     trace.Tracer
+	breaker    *breaker
+
+	attemptTimeout time.Duration
+	maxRetries     int
+	backoffBase    time.Duration
+	backoffMax     time.Duration
+}
+
+func NewClient(cfg config.Config, m *metrics.Metrics, logger *slog.Logger) *Client {
+	return &Client{
+		// Every attempt is a client span and sends its traceparent.
+		httpClient: &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport,
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return r.Method + " " + r.URL.Path
+			}),
+		)},
+		baseURL:        cfg.EnrichmentURL,
+		logger:         logger,
+		metrics:        m,
+		tracer:         otel.Tracer(tracerName),
+		breaker:        newBreaker(cfg.EnrichmentBreakerThreshold, cfg.EnrichmentBreakerCooldown),
+		attemptTimeout: cfg.EnrichmentTimeout,
+		maxRetries:     cfg.EnrichmentMaxRetries,
+		backoffBase:    cfg.EnrichmentBackoffBase,
+		backoffMax:     cfg.EnrichmentBackoffMax,
+	}
+}
+
+// GetSongInfo fetches the metadata of a song. Network errors and 5xx
+// responses are retried with exponential backoff and jitter until the
+// retries are exhausted or ctx is done.
+func (c *Client) GetSongInfo(ctx context.Context, group, song string) (info Info, err error) {
+	ctx, span := c.tracer.Start(ctx, "enrichment.GetSongInfo")
+	defer func() { tracing.End(span, err) }()
+
+	for attempt := 0; ; attempt++ {
+		span.SetAttributes(attribute.Int("music.enrichment.attempts", attempt+1))
+
+		if !c.breaker.allow() {
+			c.metrics.CountEnrichmentSkipped(metrics.OutcomeCircuitOpen)
+			return Info{}, ErrCircuitOpen
+		}
+
+		start := time.Now()
+		info, err = c.doAttempt(ctx, group, song)
+		c.metrics.ObserveEnrichmentCall(outcome(err), time.Since(start))
+		if err == nil {
+			c.breaker.success()
+			return info, nil
+		}
+
+		if ctx.Err() != nil {
+			// The caller gave up or the service is shutting down, which tells
+			// nothing of the API.
+			c.breaker.abandon()
+			return Info{}, err
+		}
+
+		if !retryable(err) {
+			// The API answered, so it is up even if it did not like the request.
+			c.breaker.success()
+			return Info{}, err
+		}
+
+		c.breaker.failure()
+
+		if attempt >= c.maxRetries {
+			return Info{}, err
+		}
+
+		delay := c.backoff(attempt)
+
+		c.logger.DebugContext(ctx, "Retrying song info request",
+			slog.Int("attempt", attempt+1),
+			slog.Duration("delay", delay),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return Info{}, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) doAttempt(ctx context.Context, group, song string) (Info, error) {
+	var info Info
+
+	if c.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.attemptTimeout)
+		defer cancel()
+	}
+
+	query := url.Values{}
+	query.Add("group", group)
+	query.Add("song", song)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/info?"+query.Encode(), nil)
+	if err != nil {
+		return info, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return info, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return info, fmt.Errorf("%w: %w", errInvalidResponse, err)
+	}
+
+	info.Text = escapedLineBreaks.Replace(info.Text)
+
+	return info, nil
+}
+
+// backoff returns a random delay in [0, min(base*2^attempt, max)).
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.backoffBase << attempt
+	if d <= 0 || (c.backoffMax > 0 && d > c.backoffMax) {
+		d = c.backoffMax
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	return rand.N(d)
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	// Transport errors, attempt timeouts and truncated bodies. Timeouts of
+	// the caller are told apart by GetSongInfo before.
+	return true
+}
+
+// outcome classifies the result of an attempt for the metrics.
+func outcome(err error) string {
+	var statusErr *StatusError
+
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusInternalServerError:
+		return metrics.OutcomeServerError
+	case errors.As(err, &statusErr):
+		return metrics.OutcomeClientError
+	case errors.Is(err, errInvalidResponse):
+		return metrics.OutcomeInvalidResponse
+	case errors.Is(err, context.DeadlineExceeded):
+		return metrics.OutcomeTimeout
+	default:
+		return metrics.OutcomeNetworkError
+	}
+}
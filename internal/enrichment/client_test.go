@@ -0,0 +1,266 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"music/internal/config"
+	"music/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// songInfoAPI serves /info with the statuses of statuses in turn, the last
+// one repeated, and counts the requests.
+type songInfoAPI struct {
+	*httptest.Server
+
+	statuses []int
+	requests atomic.Int32
+}
+
+func newSongInfoAPI(t *testing.T, statuses ...int) *songInfoAPI {
+	t.Helper()
+
+	api := &songInfoAPI{statuses: statuses}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(api.requests.Add(1))
+		status := api.statuses[min(n, len(api.statuses))-1]
+
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"relaseDate":"16.07.2006","text":"Ooh baby","link":"https://example.com"}`)
+	}))
+	t.Cleanup(api.Close)
+
+	return api
+}
+
+func (api *songInfoAPI) setStatuses(statuses ...int) {
+	api.statuses = statuses
+	api.requests.Store(0)
+}
+
+func newTestClient(baseURL string, maxRetries, threshold int) *Client {
+	return NewClient(config.Config{
+		EnrichmentURL:              baseURL,
+		EnrichmentTimeout:          time.Second,
+		EnrichmentMaxRetries:       maxRetries,
+		EnrichmentBackoffBase:      time.Millisecond,
+		EnrichmentBackoffMax:       time.Millisecond,
+		EnrichmentBreakerThreshold: threshold,
+		EnrichmentBreakerCooldown:  time.Minute,
+	}, metrics.New(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// fakeClock makes the cooldown of the breaker of c pass on demand.
+func fakeClock(c *Client) func(time.Duration) {
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestRetriesServerErrors(t *testing.T) {
+	api := newSongInfoAPI(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	client := newTestClient(api.URL, 3, 0)
+
+	info, err := client.GetSongInfo(context.Background(), "Muse", "Uprising")
+	if err != nil {
+		t.Fatalf("GetSongInfo: %v", err)
+	}
+
+	if info.Text != "Ooh baby" {
+		t.Errorf("GetSongInfo = %+v", info)
+	}
+
+	if got := api.requests.Load(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestGivesUpAfterRetries(t *testing.T) {
+	api := newSongInfoAPI(t, http.StatusServiceUnavailable)
+	client := newTestClient(api.URL, 2, 0)
+
+	_, err := client.GetSongInfo(context.Background(), "Muse", "Uprising")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("GetSongInfo = %v, want a 503 StatusError", err)
+	}
+
+	if got := api.requests.Load(); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+}
+
+func TestDoesNotRetryClientErrors(t *testing.T) {
+	api := newSongInfoAPI(t, http.StatusNotFound)
+	client := newTestClient(api.URL, 3, 1)
+
+	_, err := client.GetSongInfo(context.Background(), "Muse", "Unknown")
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("GetSongInfo = %v, want a 404 StatusError", err)
+	}
+
+	if got := api.requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+
+	// The API answered, the breaker stays closed.
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Unknown"); errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("GetSongInfo after a 404 = %v, want the breaker closed", err)
+	}
+}
+
+func TestBreakerOpensAfterFailures(t *testing.T) {
+	api := newSongInfoAPI(t, http.StatusInternalServerError)
+	client := newTestClient(api.URL, 0, 2)
+
+	for range 2 {
+		if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("GetSongInfo before the threshold = %v", err)
+		}
+	}
+
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetSongInfo after the threshold = %v, want ErrCircuitOpen", err)
+	}
+
+	if got := api.requests.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2 as the open breaker sends none", got)
+	}
+}
+
+func TestBreakerProbeCloses(t *testing.T) {
+	api := newSongInfoAPI(t, http.StatusInternalServerError)
+	client := newTestClient(api.URL, 0, 1)
+	advance := fakeClock(client)
+
+	client.GetSongInfo(context.Background(), "Muse", "Uprising")
+
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetSongInfo during the cooldown = %v, want ErrCircuitOpen", err)
+	}
+
+	advance(time.Minute)
+	api.setStatuses(http.StatusOK)
+
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("GetSongInfo as the probe: %v", err)
+	}
+
+	for range 3 {
+		if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); err != nil {
+			t.Fatalf("GetSongInfo after a successful probe: %v", err)
+		}
+	}
+
+	if got := api.requests.Load(); got != 4 {
+		t.Errorf("got %d requests after the cooldown, want 4", got)
+	}
+}
+
+func TestBreakerProbeFailureReopens(t *testing.T) {
+	api := newSongInfoAPI(t, http.StatusInternalServerError)
+	client := newTestClient(api.URL, 0, 1)
+	advance := fakeClock(client)
+
+	client.GetSongInfo(context.Background(), "Muse", "Uprising")
+	advance(time.Minute)
+
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetSongInfo after the cooldown = %v, want a probe", err)
+	}
+
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetSongInfo after a failed probe = %v, want ErrCircuitOpen", err)
+	}
+
+	if got := api.requests.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestBreakerLetsOneProbeThrough(t *testing.T) {
+	b := newBreaker(1, time.Minute)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	now = now.Add(time.Minute)
+
+	if !b.allow() {
+		t.Fatal("allow after the cooldown = false, want the probe")
+	}
+
+	if b.allow() {
+		t.Error("allow while the probe is in flight = true, want false")
+	}
+
+	// A probe canceled by its caller lets the next call probe.
+	b.abandon()
+
+	if !b.allow() {
+		t.Error("allow after an abandoned probe = false, want a new probe")
+	}
+}
+
+func TestCanceledCallsDoNotOpenBreaker(t *testing.T) {
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(api.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := newTestClient(api.URL, 3, 1)
+
+	for range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := client.GetSongInfo(ctx, "Muse", "Uprising")
+		cancel()
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("GetSongInfo with an expired context = %v, want DeadlineExceeded", err)
+		}
+	}
+
+	client.breaker.mu.Lock()
+	state, failures := client.breaker.state, client.breaker.failures
+	client.breaker.mu.Unlock()
+
+	if state != stateClosed || failures != 0 {
+		t.Errorf("breaker is %v with %d failures, want closed without failures", state, failures)
+	}
+}
+
+func TestUnescapesLyrics(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"relaseDate":"16.07.2006","text":"Ooh baby\\nOoh\\n\\nYou set my soul alight","link":""}`)
+	}))
+	t.Cleanup(api.Close)
+
+	info, err := newTestClient(api.URL, 0, 0).GetSongInfo(context.Background(), "Muse", "Supermassive Black Hole")
+	if err != nil {
+		t.Fatalf("GetSongInfo: %v", err)
+	}
+
+	if want := "Ooh baby\nOoh\n\nYou set my soul alight"; info.Text != want {
+		t.Errorf("GetSongInfo text = %q, want %q", info.Text, want)
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"music/internal/controller"
+	"music/internal/models"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -20,22 +21,82 @@ func NewHandler(controller *controller.Controller) *Handler {
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	// Handlers pass the gin context on, it has to carry the values and the
+	// cancellation of the request context, such as the audit.
+	router.ContextWithFallback = true
+
+	// The span and the request ID come first so that the access log and the
+	// panics of every later handler carry them. Requests are metered outside
+	// of the recovery so that panics count as the 500 they are answered with.
+	router.Use(h.controller.Traced, h.controller.Audited, h.controller.Logged, h.controller.Metered, h.controller.Recovered)
 
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:  []string{"http://*", "https://*"},
-		AllowMethods:  []string{"GET", "POST", "PATCH", "DELETE"},
-		AllowHeaders:  []string{"Accept", "Authorization", "Content-Type"},
-		ExposeHeaders: []string{"Content-Length"},
-		MaxAge:        12 * time.Hour,
+		AllowOrigins: []string{"http://*", "https://*"},
+		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders: []string{
+			"Accept", "Authorization", "Content-Type", "Idempotency-Key", "If-Match", "If-None-Match", "X-API-Key", "X-Request-ID",
+			"traceparent", "tracestate", "baggage",
+		},
+		ExposeHeaders: []string{
+			"Content-Length", "Idempotent-Replayed", "ETag", "Location", "Deprecation", "Link", "WWW-Authenticate", "X-Request-ID",
+			"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After",
+		},
+		MaxAge: 12 * time.Hour,
 	}))
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	router.GET("", h.controller.GetMusics)
-	router.GET(":music_id", h.controller.GetSongLyricsByVerses)
-	router.POST("", h.controller.AddMusic)
-	router.PATCH(":music_id", h.controller.UpdateMusic)
-	router.DELETE(":music_id", h.controller.DeleteMusic)
+	// Requests are not authenticated yet, so every client IP has a bucket
+	// and invalid credentials are throttled as well.
+	router.Use(h.controller.RateLimited(models.RateLimitAuth), h.controller.Authenticated)
+
+	// Denied requests count against the rate limit too. The role is checked
+	// before an idempotent response is stored, so that a denied request can
+	// be retried with credentials.
+	read := router.Group("",
+		h.controller.RateLimited(models.RateLimitRead), h.controller.Require(models.RoleReadOnly))
+	write := router.Group("",
+		h.controller.RateLimited(models.RateLimitWrite), h.controller.Require(models.RoleEditor), h.controller.Idempotent)
+	// Created API keys are not stored with idempotent responses.
+	admin := router.Group("",
+		h.controller.RateLimited(models.RateLimitWrite), h.controller.Require(models.RoleAdmin))
+
+	read.GET("", h.controller.GetMusics)
+	read.GET("search", h.controller.SearchMusics)
+	read.GET("export", h.controller.ExportMusics)
+	read.GET("trash", h.controller.GetDeletedMusics)
+	read.GET(":music_id", h.controller.GetMusic)
+	read.GET(":music_id/lyrics", h.controller.GetSongLyricsByVerses)
+	write.POST("", h.controller.AddMusic)
+	write.PATCH(":music_id", h.controller.UpdateMusic)
+	write.PUT(":music_id", h.controller.ReplaceMusic)
+	write.DELETE(":music_id", h.controller.DeleteMusic)
+	read.GET(":music_id/enrichment", h.controller.GetEnrichmentStatus)
+	write.POST(":music_id/restore", h.controller.RestoreMusic)
+	read.GET(":music_id/history", h.controller.GetMusicHistory)
+	write.POST(":music_id/revert/:rev", h.controller.RevertMusic)
+	write.POST("import", h.controller.ImportMusics)
+
+	artists := read.Group("artists")
+	{
+		artists.GET("", h.controller.GetArtists)
+		artists.GET(":artist_id", h.controller.GetArtist)
+		artists.GET(":artist_id/songs", h.controller.GetArtistSongs)
+	}
+
+	editArtists := write.Group("artists")
+	{
+		editArtists.POST("", h.controller.AddArtist)
+		editArtists.PATCH(":artist_id", h.controller.RenameArtist)
+		editArtists.DELETE(":artist_id", h.controller.DeleteArtist)
+	}
+
+	apiKeys := admin.Group("api-keys")
+	{
+		apiKeys.GET("", h.controller.GetAPIKeys)
+		apiKeys.POST("", h.controller.AddAPIKey)
+		apiKeys.DELETE(":key_id", h.controller.RevokeAPIKey)
+	}
 
 	return router
 }
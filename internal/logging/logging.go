@@ -0,0 +1,104 @@
+// Package logging carries the logger of a request in its context, so that
+// every layer logs the request it works for.
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestLog is the logger of a request and the attributes it was given.
+type requestLog struct {
+	logger *slog.Logger
+	attrs  []slog.Attr
+}
+
+type requestLogKey struct{}
+
+// WithLogger returns a copy of ctx that carries logger with attrs, such as
+// the ID of the request, as the logger of the request.
+func WithLogger(ctx context.Context, logger *slog.Logger, attrs ...slog.Attr) context.Context {
+	handler := logger.Handler()
+	if h, ok := handler.(contextHandler); ok {
+		h.request = true
+		handler = h
+	}
+
+	return context.WithValue(ctx, requestLogKey{}, requestLog{
+		logger: slog.New(handler).With(attrsToAny(attrs)...),
+		attrs:  attrs,
+	})
+}
+
+// With returns a copy of ctx whose logger has attrs added, as the request
+// goes on. It does nothing when ctx carries no logger.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	current, ok := ctx.Value(requestLogKey{}).(requestLog)
+	if !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, requestLogKey{}, requestLog{
+		logger: current.logger.With(attrsToAny(attrs)...),
+		attrs:  append(current.attrs[:len(current.attrs):len(current.attrs)], attrs...),
+	})
+}
+
+// LoggerFrom returns the logger of the request ctx belongs to, with the
+// attributes of the request, or slog.Default() outside of a request.
+func LoggerFrom(ctx context.Context) *slog.Logger {
+	if current, ok := ctx.Value(requestLogKey{}).(requestLog); ok {
+		return current.logger
+	}
+
+	return slog.Default()
+}
+
+func attrsToAny(attrs []slog.Attr) []any {
+	args := make([]any, len(attrs))
+	for i, attr := range attrs {
+		args[i] = attr
+	}
+
+	return args
+}
+
+// Handler wraps handler so that the loggers the layers were built with log
+// the request they work for too: records logged with the context of a
+// request get its attributes, and records logged within a span get its
+// trace_id and span_id.
+func Handler(handler slog.Handler) slog.Handler {
+	return contextHandler{Handler: handler}
+}
+
+type contextHandler struct {
+	slog.Handler
+	// request is set for the logger of a request, which has the attributes
+	// of the request already.
+	request bool
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if current, ok := ctx.Value(requestLogKey{}).(requestLog); ok && !h.request {
+		record.AddAttrs(current.attrs...)
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs), request: h.request}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name), request: h.request}
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+// record runs log and returns the keys of the record it wrote, failing on
+// a key written twice.
+func record(t *testing.T, out *bytes.Buffer, log func()) map[string]any {
+	t.Helper()
+
+	out.Reset()
+	log()
+
+	var keys map[string]any
+
+	decoder := json.NewDecoder(bytes.NewReader(out.Bytes()))
+	if err := decoder.Decode(&keys); err != nil {
+		t.Fatalf("record %q: %v", out.String(), err)
+	}
+
+	// encoding/json keeps the last of duplicate keys, count them by hand.
+	for key := range keys {
+		if n := bytes.Count(out.Bytes(), []byte(`"`+key+`":`)); n != 1 {
+			t.Errorf("record %q has %s %d times", out.String(), key, n)
+		}
+	}
+
+	return keys
+}
+
+func TestLoggerFrom(t *testing.T) {
+	var out bytes.Buffer
+
+	logger := slog.New(Handler(slog.NewJSONHandler(&out, nil)))
+
+	ctx := WithLogger(context.Background(), logger, slog.String("request_id", "r1"))
+	ctx = With(ctx, slog.String("principal", "alice"))
+
+	tests := []struct {
+		name string
+		log  func()
+	}{
+		{"logger of the request", func() { LoggerFrom(ctx).InfoContext(ctx, "msg") }},
+		{"logger of a layer", func() { logger.InfoContext(ctx, "msg") }},
+		{"logger of the request with attributes", func() { LoggerFrom(ctx).With("id", 1).InfoContext(ctx, "msg") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := record(t, &out, tt.log)
+
+			if keys["request_id"] != "r1" || keys["principal"] != "alice" {
+				t.Errorf("record = %v, want the request_id and principal of the request", keys)
+			}
+		})
+	}
+}
+
+func TestLoggerFromOutsideRequest(t *testing.T) {
+	ctx := With(context.Background(), slog.String("principal", "alice"))
+
+	if logger := LoggerFrom(ctx); logger != slog.Default() {
+		t.Errorf("LoggerFrom = %v, want slog.Default()", logger)
+	}
+}
@@ -0,0 +1,131 @@
+// Package metrics holds the Prometheus collectors of the service and serves
+// them on /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "music"
+
+// Outcomes of the calls made to the song info API.
+const (
+	OutcomeSuccess         = "success"
+	OutcomeClientError     = "client_error"
+	OutcomeServerError     = "server_error"
+	OutcomeTimeout         = "timeout"
+	OutcomeNetworkError    = "network_error"
+	OutcomeInvalidResponse = "invalid_response"
+	OutcomeCircuitOpen     = "circuit_open"
+)
+
+// Outcomes of the calls made to the song repository besides OutcomeSuccess.
+// Requests the domain turns down are told apart from failures of the
+// database.
+const (
+	OutcomeNotFound = "not_found"
+	OutcomeConflict = "conflict"
+	OutcomeRejected = "rejected"
+	OutcomeError    = "error"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	dbQueryDuration    *prometheus.HistogramVec
+	enrichmentCalls    *prometheus.CounterVec
+	enrichmentDuration *prometheus.HistogramVec
+}
+
+// New returns the collectors of the service along with the Go runtime and
+// process ones, registered in a registry of their own.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time to handle HTTP requests by method, route pattern and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Time spent in the song repository by method and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "outcome"}),
+		enrichmentCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "enrichment_requests_total",
+			Help:      "Calls to the song info API by outcome, circuit_open ones were not sent.",
+		}, []string{"outcome"}),
+		enrichmentDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "enrichment_request_duration_seconds",
+			Help:      "Latency of the calls to the song info API by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.dbQueryDuration,
+		m.enrichmentCalls,
+		m.enrichmentDuration,
+	)
+
+	return m
+}
+
+// RegisterDB exports the connection pool stats of db.
+func (m *Metrics) RegisterDB(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTPRequest records a handled request. route is the pattern of the
+// route, such as /:music_id, so that IDs do not make new series.
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, d time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(d.Seconds())
+}
+
+// ObserveDBQuery records a call of a repository method.
+func (m *Metrics) ObserveDBQuery(method, outcome string, d time.Duration) {
+	m.dbQueryDuration.WithLabelValues(method, outcome).Observe(d.Seconds())
+}
+
+// ObserveEnrichmentCall records a call to the song info API.
+func (m *Metrics) ObserveEnrichmentCall(outcome string, d time.Duration) {
+	m.enrichmentCalls.WithLabelValues(outcome).Inc()
+	m.enrichmentDuration.WithLabelValues(outcome).Observe(d.Seconds())
+}
+
+// CountEnrichmentSkipped records a call to the song info API that was not
+// sent, such as while the circuit breaker is open.
+func (m *Metrics) CountEnrichmentSkipped(outcome string) {
+	m.enrichmentCalls.WithLabelValues(outcome).Inc()
+}
@@ -0,0 +1,10 @@
+package models
+
+type Artist struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ArtistInput struct {
+	Name string `json:"name" binding:"required"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// Role is what a principal may do. Each role includes the ones before it:
+// read-only reads the catalog, editor changes it and admin also manages the
+// API keys.
+type Role string
+
+const (
+	RoleReadOnly Role = "read-only"
+	RoleEditor   Role = "editor"
+	RoleAdmin    Role = "admin"
+)
+
+var roleRanks = map[Role]int{RoleReadOnly: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	return roleRanks[r] > 0
+}
+
+// Includes reports whether r may do everything required may do. Unknown
+// roles include nothing.
+func (r Role) Includes(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// Ways a principal can authenticate.
+const (
+	AuthAPIKey = "api_key"
+	AuthToken  = "jwt"
+)
+
+// Principal is who makes a request. An anonymous principal has no Subject,
+// its Role is the one granted to requests without credentials, if any.
+type Principal struct {
+	Subject string
+	Role    Role
+	// Method is AuthAPIKey or AuthToken, empty for anonymous principals.
+	Method string
+}
+
+// Authenticated reports whether the principal sent valid credentials.
+func (p Principal) Authenticated() bool {
+	return p.Subject != ""
+}
+
+// Credentials are what a request sends to authenticate, at most one of them.
+type Credentials struct {
+	APIKey string
+	Token  string
+}
+
+func (c Credentials) Empty() bool {
+	return c.APIKey == "" && c.Token == ""
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal carried by ctx, anonymous when there
+// is none.
+func PrincipalFrom(ctx context.Context) Principal {
+	principal, _ := ctx.Value(principalKey{}).(Principal)
+	return principal
+}
+
+// APIKey is a static key a client sends in the X-API-Key header. Only a hash
+// of the key is stored, Key is set once, when the key is created.
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Role      Role       `json:"role" swaggertype:"string" enums:"read-only,editor,admin"`
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyInput struct {
+	Name string `json:"name" binding:"required"`
+	Role Role   `json:"role" binding:"required" swaggertype:"string" enums:"read-only,editor,admin"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"cmp"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the formats ParseDate accepts: ISO 8601 dates, alone or
+// within a timestamp, and DD.MM.YYYY as the info API sends them.
+var dateLayouts = []string{time.DateOnly, time.RFC3339, "02.01.2006"}
+
+// Date is a calendar date, such as the release date of a song. The zero Date
+// is no date: it is stored as NULL and written as an empty string. Dates are
+// always written as YYYY-MM-DD.
+type Date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// NewDate returns the date of the given day, normalized as time.Date does.
+func NewDate(year int, month time.Month, day int) Date {
+	return DateOf(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// DateOf returns the date of t in its location.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+
+	return Date{year: year, month: month, day: day}
+}
+
+// ParseDate parses a date in one of dateLayouts, surrounding spaces are
+// ignored. An empty string is the zero Date. Invalid dates fail with
+// ErrValidation.
+func ParseDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Date{}, nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return DateOf(t), nil
+		}
+	}
+
+	return Date{}, fmt.Errorf("%w: invalid date %q, expected YYYY-MM-DD or DD.MM.YYYY", ErrValidation, s)
+}
+
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// String returns the date as YYYY-MM-DD, or an empty string for no date.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Time().Format(time.DateOnly)
+}
+
+// Time returns the midnight UTC that starts the date.
+func (d Date) Time() time.Time {
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC)
+}
+
+// Compare returns -1, 0 or +1 as d is before, equal to or after other. No
+// date is before every date.
+func (d Date) Compare(other Date) int {
+	return cmp.Or(cmp.Compare(d.year, other.year), cmp.Compare(d.month, other.month), cmp.Compare(d.day, other.day))
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	date, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = date
+
+	return nil
+}
+
+// Scan reads a DATE column, NULL is the zero Date.
+func (d *Date) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = DateOf(src)
+	case []byte:
+		return d.UnmarshalText(src)
+	case string:
+		return d.UnmarshalText([]byte(src))
+	default:
+		return fmt.Errorf("cannot scan %T into a date", src)
+	}
+
+	return nil
+}
+
+// Value writes the date as YYYY-MM-DD, the zero Date as NULL.
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+
+	return d.String(), nil
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Date
+		wantErr bool
+	}{
+		{"2006-07-16", NewDate(2006, time.July, 16), false},
+		{"16.07.2006", NewDate(2006, time.July, 16), false},
+		{" 16.07.2006 ", NewDate(2006, time.July, 16), false},
+		{"2006-07-16T23:30:00+05:00", NewDate(2006, time.July, 16), false},
+		{"2006-07-16T01:00:00Z", NewDate(2006, time.July, 16), false},
+		{"29.02.2020", NewDate(2020, time.February, 29), false},
+		{"", Date{}, false},
+		{"  ", Date{}, false},
+		{"31.02.2020", Date{}, true},
+		{"29.02.2021", Date{}, true},
+		{"2006-13-01", Date{}, true},
+		{"16/07/2006", Date{}, true},
+		{"16.7.2006", Date{}, true},
+		{"yesterday", Date{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDate(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("ParseDate(%q) = %v, %v, want %v", tt.in, got, err, ErrValidation)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("ParseDate(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateJSON(t *testing.T) {
+	type song struct {
+		RelaseDate Date `json:"release_date"`
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ISO date", `{"release_date":"2006-07-16"}`, `{"release_date":"2006-07-16"}`},
+		{"DD.MM.YYYY", `{"release_date":"16.07.2006"}`, `{"release_date":"2006-07-16"}`},
+		{"timestamp", `{"release_date":"2006-07-16T10:00:00Z"}`, `{"release_date":"2006-07-16"}`},
+		{"empty", `{"release_date":""}`, `{"release_date":""}`},
+		{"missing", `{}`, `{"release_date":""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s song
+			if err := json.Unmarshal([]byte(tt.in), &s); err != nil {
+				t.Fatalf("Unmarshal(%s): %v", tt.in, err)
+			}
+
+			out, err := json.Marshal(s)
+			if err != nil || string(out) != tt.want {
+				t.Errorf("Marshal(Unmarshal(%s)) = %s, %v, want %s", tt.in, out, err, tt.want)
+			}
+		})
+	}
+
+	var s song
+	if err := json.Unmarshal([]byte(`{"release_date":"31.02.2020"}`), &s); !errors.Is(err, ErrValidation) {
+		t.Errorf("Unmarshal of an invalid date = %v, want %v", err, ErrValidation)
+	}
+}
+
+func TestDateScanValue(t *testing.T) {
+	date := NewDate(2006, time.July, 16)
+
+	tests := []struct {
+		name string
+		src  any
+		want Date
+	}{
+		{"NULL", nil, Date{}},
+		{"time", time.Date(2006, time.July, 16, 0, 0, 0, 0, time.UTC), date},
+		{"bytes", []byte("2006-07-16"), date},
+		{"string", "2006-07-16", date},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewDate(1999, time.January, 1)
+			if err := got.Scan(tt.src); err != nil || got != tt.want {
+				t.Errorf("Scan(%v) = %v, %v, want %v", tt.src, got, err, tt.want)
+			}
+		})
+	}
+
+	var got Date
+	if err := got.Scan(42); err == nil {
+		t.Errorf("Scan(42) = %v, want an error", got)
+	}
+
+	for _, tt := range []struct {
+		date Date
+		want driver.Value
+	}{
+		{date, "2006-07-16"},
+		{Date{}, nil},
+	} {
+		if value, err := tt.date.Value(); err != nil || value != tt.want {
+			t.Errorf("%v.Value() = %v, %v, want %v", tt.date, value, err, tt.want)
+		}
+	}
+}
+
+func TestDateCompare(t *testing.T) {
+	dates := []Date{{}, NewDate(2005, time.December, 31), NewDate(2006, time.January, 1), NewDate(2006, time.July, 16)}
+
+	for i, a := range dates {
+		for j, b := range dates {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+
+			if got := a.Compare(b); got != want {
+				t.Errorf("%q.Compare(%q) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Domain errors shared by the repository, service and controller layers.
+// Implementations wrap them with details, callers match them with errors.Is.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrNoUpdates          = errors.New("no updates provided")
+	ErrConflict           = errors.New("conflict")
+	ErrValidation         = errors.New("validation failed")
+	ErrOutOfRange         = errors.New("out of range")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrUnauthorized       = errors.New("unauthorized")
+)
+
+// SongExistsError is returned when a song with the same group and title,
+// ignoring case, is already stored. It matches ErrConflict.
+type SongExistsError struct {
+	ID int
+}
+
+func (e *SongExistsError) Error() string {
+	return fmt.Sprintf("%v: song already exists with id %d", ErrConflict, e.ID)
+}
+
+func (e *SongExistsError) Unwrap() error {
+	return ErrConflict
+}
@@ -0,0 +1,111 @@
+package models
+
+// MatchMode tells how a text filter is compared with the field.
+type MatchMode string
+
+const (
+	MatchContains MatchMode = "contains"
+	MatchExact    MatchMode = "exact"
+)
+
+// Fields the song listing can be sorted by.
+const (
+	SortGroup       = "group"
+	SortSong        = "song"
+	SortReleaseDate = "release_date"
+	SortID          = "id"
+)
+
+// TextFilter matches a field ignoring case, either as a substring or as the
+// whole value. An empty Value matches everything.
+type TextFilter struct {
+	Value string
+	Mode  MatchMode
+}
+
+// Exact reports whether the whole value has to match.
+func (f TextFilter) Exact() bool {
+	return f.Mode == MatchExact
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// MusicFilter selects songs for the list endpoint. Zero dates are not
+// applied, the release date bounds are inclusive.
+type MusicFilter struct {
+	Group TextFilter
+	Song  TextFilter
+	Text  TextFilter
+
+	ReleaseDate    Date
+	ReleasedAfter  Date
+	ReleasedBefore Date
+
+	Sort []SortField
+
+	Limit  int
+	Offset int
+	// After continues a keyset pagination. When set, Offset is ignored.
+	After *Cursor
+}
+
+// SortKeys returns the order of the listing: Sort followed by id as the
+// tiebreaker. Fields after id are dropped as id is unique.
+func (f MusicFilter) SortKeys() []SortField {
+	keys := make([]SortField, 0, len(f.Sort)+1)
+
+	for _, field := range f.Sort {
+		keys = append(keys, field)
+
+		if field.Field == SortID {
+			return keys
+		}
+	}
+
+	return append(keys, SortField{Field: SortID})
+}
+
+// Cursor is the position of the last song of a page: the values of its sort
+// keys other than id, in SortKeys order, and its ID.
+type Cursor struct {
+	Values []string `json:"v,omitempty"`
+	ID     int      `json:"id"`
+}
+
+// NewCursor returns the position of music in a listing ordered by keys.
+func NewCursor(music MusicInfo, keys []SortField) Cursor {
+	cursor := Cursor{ID: music.ID}
+
+	for _, key := range keys {
+		if key.Field != SortID {
+			cursor.Values = append(cursor.Values, music.SortValue(key.Field))
+		}
+	}
+
+	return cursor
+}
+
+// SortValue returns the value of a sort field other than id. Songs without
+// a release date have an empty one, which sorts first.
+func (m MusicInfo) SortValue(field string) string {
+	switch field {
+	case SortGroup:
+		return m.Group
+	case SortSong:
+		return m.Song
+	case SortReleaseDate:
+		return m.RelaseDate.String()
+	default:
+		return ""
+	}
+}
+
+// MusicPage is a page of a keyset paginated song listing.
+type MusicPage struct {
+	Items      []MusicInfo `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// IdempotencyRecord is a request made with an Idempotency-Key header and,
+// once Completed, its response.
+type IdempotencyRecord struct {
+	// Fingerprint is a hash of the request, a key reused for another request
+	// is rejected.
+	Fingerprint string
+	Completed   bool
+
+	Status int
+	Header map[string]string
+	Body   []byte
+}
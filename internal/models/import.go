@@ -0,0 +1,61 @@
+package models
+
+// ImportFormat is the encoding of an imported catalog.
+type ImportFormat string
+
+const (
+	ImportNDJSON ImportFormat = "ndjson"
+	ImportCSV    ImportFormat = "csv"
+)
+
+// ImportRow is a song of an imported catalog. Rows with any of the release
+// date, lyrics or link are stored as they are and skip the enrichment.
+type ImportRow struct {
+	Group      string `json:"group"`
+	Song       string `json:"song"`
+	RelaseDate string `json:"release_date"`
+	Text       string `json:"text"`
+	Link       string `json:"link"`
+}
+
+// HasDetails reports whether the row carries its own metadata.
+func (r ImportRow) HasDetails() bool {
+	return r.RelaseDate != "" || r.Text != "" || r.Link != ""
+}
+
+type ImportStatus string
+
+const (
+	ImportCreated   ImportStatus = "created"
+	ImportDuplicate ImportStatus = "skipped_duplicate"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// ImportRowResult is the outcome of a row, Line is its line in the input.
+type ImportRowResult struct {
+	Line   int          `json:"line"`
+	Status ImportStatus `json:"status"`
+	ID     int          `json:"id,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Created    int               `json:"created"`
+	Duplicates int               `json:"skipped_duplicates"`
+	Failed     int               `json:"failed"`
+	Rows       []ImportRowResult `json:"rows"`
+}
+
+// Add records the outcome of a row.
+func (r *ImportReport) Add(result ImportRowResult) {
+	switch result.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportDuplicate:
+		r.Duplicates++
+	case ImportFailed:
+		r.Failed++
+	}
+
+	r.Rows = append(r.Rows, result)
+}
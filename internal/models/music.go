@@ -1,23 +1,133 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+const (
+	EnrichmentPending  = "pending"
+	EnrichmentEnriched = "enriched"
+	EnrichmentFailed   = "failed"
+)
+
 type Music struct {
 	Group string `json:"group" binding:"required"`
 	Song  string `json:"song" binding:"required"`
 }
 
 type MusicInfo struct {
-	ID         int    `json:"id"`
-	Group      string `json:"group"`
-	Song       string `json:"song"`
-	RelaseDate string `json:"release_date"`
+	ID               int    `json:"id"`
+	Group            string `json:"group"`
+	Song             string `json:"song"`
+	RelaseDate       Date   `json:"release_date" swaggertype:"string" format:"date"`
+	Text             string `json:"text"`
+	Link             string `json:"link"`
+	EnrichmentStatus string `json:"enrichment_status"`
+	// Version starts at 1 and grows with every change of the song.
+	Version int `json:"version"`
+}
+
+// MusicUpdate is a JSON Merge Patch of a song: members left out are kept,
+// null clears the field and other values replace it. Only the fields below
+// can be updated, other members are rejected with ErrValidation.
+type MusicUpdate struct {
+	Group      PatchField[string] `json:"group" swaggertype:"string"`
+	Song       PatchField[string] `json:"song" swaggertype:"string"`
+	RelaseDate PatchField[Date]   `json:"release_date" swaggertype:"string" format:"date"`
+	Text       PatchField[string] `json:"text" swaggertype:"string"`
+	Link       PatchField[string] `json:"link" swaggertype:"string"`
+}
+
+// updatableFields are the members a MusicUpdate accepts.
+var updatableFields = []string{"group", "song", "release_date", "text", "link"}
+
+func (u *MusicUpdate) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &members); err != nil {
+		return errors.New("merge patch must be a JSON object")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(members)) {
+		if !slices.Contains(updatableFields, name) {
+			return fmt.Errorf("%w: %s cannot be updated", ErrValidation, name)
+		}
+	}
+
+	type musicUpdate MusicUpdate
+
+	return json.Unmarshal(data, (*musicUpdate)(u))
+}
+
+// Empty reports whether the update changes nothing.
+func (u MusicUpdate) Empty() bool {
+	return u == MusicUpdate{}
+}
+
+// MusicReplacement is a full update of a song, fields left out are cleared.
+type MusicReplacement struct {
+	Group      string `json:"group" binding:"required"`
+	Song       string `json:"song" binding:"required"`
+	RelaseDate Date   `json:"release_date" swaggertype:"string" format:"date"`
 	Text       string `json:"text"`
 	Link       string `json:"link"`
 }
 
-type MusicUpdate struct {
-	Group      string `json:"group" db:"music_group"`
-	Song       string `json:"song" db:"song"`
-	RelaseDate string `json:"release_date" db:"release_date"`
-	Text       string `json:"text" db:"text"`
-	Link       string `json:"link" db:"link"`
+// Update returns the update that replaces every field of a song with r.
+func (r MusicReplacement) Update() MusicUpdate {
+	return MusicUpdate{
+		Group:      Set(r.Group),
+		Song:       Set(r.Song),
+		RelaseDate: setOrClear(r.RelaseDate),
+		Text:       setOrClear(r.Text),
+		Link:       setOrClear(r.Link),
+	}
+}
+
+// setOrClear returns a field that clears the value when value is empty.
+func setOrClear[T comparable](value T) PatchField[T] {
+	var zero T
+	if value == zero {
+		return Clear[T]()
+	}
+
+	return Set(value)
+}
+
+// Lyrics is a page of the verses of a song. Couplet is the position of the
+// first verse, counted from 1.
+type Lyrics struct {
+	Verses      []string `json:"verses"`
+	Couplet     int      `json:"couplet"`
+	Size        int      `json:"size"`
+	TotalVerses int      `json:"total_verses"`
+	HasNext     bool     `json:"has_next"`
+}
+
+type EnrichmentStatus struct {
+	ID         int        `json:"id"`
+	Status     string     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error,omitempty"`
+	NextAt     *time.Time `json:"next_attempt_at,omitempty"`
+	EnrichedAt *time.Time `json:"enriched_at,omitempty"`
+}
+
+// EnrichmentTask is a song waiting for its metadata from the info API.
+type EnrichmentTask struct {
+	ID       int
+	Group    string
+	Song     string
+	Attempts int
+}
+
+// DeletedMusic is a song in the trash.
+type DeletedMusic struct {
+	MusicInfo
+	DeletedAt time.Time `json:"deleted_at"`
 }
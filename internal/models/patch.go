@@ -0,0 +1,43 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PatchField is a member of a JSON Merge Patch (RFC 7396). Set tells whether
+// the patch has the member and Null whether it is null, which clears the
+// field. Value is meaningful when the field is set and not null.
+type PatchField[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// Set returns a field that replaces the value with value.
+func Set[T any](value T) PatchField[T] {
+	return PatchField[T]{Value: value, Set: true}
+}
+
+// Clear returns a field that clears the value.
+func Clear[T any]() PatchField[T] {
+	return PatchField[T]{Set: true, Null: true}
+}
+
+// Replaces reports whether the field is set to a value other than null.
+func (f PatchField[T]) Replaces() bool {
+	return f.Set && !f.Null
+}
+
+// UnmarshalJSON is only called for members present in the patch, null
+// included.
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	*f = PatchField[T]{Set: true}
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		f.Null = true
+		return nil
+	}
+
+	return json.Unmarshal(data, &f.Value)
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPatchFieldUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    PatchField[string]
+		wantErr bool
+	}{
+		{"value", `{"text": "verse"}`, Set("verse"), false},
+		{"empty string", `{"text": ""}`, Set(""), false},
+		{"null", `{"text": null}`, Clear[string](), false},
+		{"missing", `{}`, PatchField[string]{}, false},
+		{"wrong type", `{"text": 1}`, PatchField[string]{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch struct {
+				Text PatchField[string] `json:"text"`
+			}
+
+			err := json.Unmarshal([]byte(tt.data), &patch)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Unmarshal(%s) = %+v, want an error", tt.data, patch.Text)
+				}
+				return
+			}
+
+			if err != nil || patch.Text != tt.want {
+				t.Errorf("Unmarshal(%s) = %+v, %v, want %+v", tt.data, patch.Text, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMusicUpdateUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		want      MusicUpdate
+		wantErr   bool
+		wantValid bool // the error is ErrValidation
+	}{
+		{"empty", `{}`, MusicUpdate{}, false, false},
+		{"clear text", `{"text": null}`, MusicUpdate{Text: Clear[string]()}, false, false},
+		{"clear group", `{"group": null}`, MusicUpdate{Group: Clear[string]()}, false, false},
+		{
+			"set",
+			`{"group": "Muse", "release_date": "16.07.2006", "link": null}`,
+			MusicUpdate{Group: Set("Muse"), RelaseDate: Set(NewDate(2006, time.July, 16)), Link: Clear[string]()},
+			false, false,
+		},
+		{"unknown member", `{"text": null, "version": 2}`, MusicUpdate{}, true, true},
+		{"array", `[{"text": null}]`, MusicUpdate{}, true, false},
+		{"string", `"text"`, MusicUpdate{}, true, false},
+		{"null", `null`, MusicUpdate{}, false, false},
+		{"invalid date", `{"release_date": "31.02.2020"}`, MusicUpdate{}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var update MusicUpdate
+
+			err := json.Unmarshal([]byte(tt.data), &update)
+			if tt.wantErr {
+				if err == nil || errors.Is(err, ErrValidation) != tt.wantValid {
+					t.Errorf("Unmarshal(%s) = %+v, %v, want an error, ErrValidation: %t", tt.data, update, err, tt.wantValid)
+				}
+				return
+			}
+
+			if err != nil || update != tt.want {
+				t.Errorf("Unmarshal(%s) = %+v, %v, want %+v", tt.data, update, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Classes of routes with their own rate limits. RateLimitAuth is taken per
+// IP before the credentials are checked, so that guessing them is slowed
+// down too.
+const (
+	RateLimitRead  = "read"
+	RateLimitWrite = "write"
+	RateLimitAuth  = "auth"
+)
+
+// RateLimit is a token bucket of Requests tokens, refilled evenly over Per.
+// A limit without requests lets everything through.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l RateLimit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// RateBucket is the state of the bucket of a client. The zero bucket is
+// full.
+type RateBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// RateLimitResult tells whether a request may go through and how the bucket
+// of its client looks afterwards.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     RateLimit
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token when the request is denied.
+	RetryAfter time.Duration
+}
+
+// Take refills bucket up to now and takes a token from it. The returned
+// bucket is the state to store, whether or not the request is allowed.
+func (l RateLimit) Take(bucket RateBucket, now time.Time) (RateBucket, RateLimitResult) {
+	capacity := float64(l.Requests)
+	perToken := l.Per / time.Duration(l.Requests)
+
+	tokens := capacity
+	if !bucket.UpdatedAt.IsZero() {
+		// Replicas may disagree on the time, a bucket is never drained by it.
+		elapsed := max(now.Sub(bucket.UpdatedAt), 0)
+		tokens = min(capacity, bucket.Tokens+float64(elapsed)/float64(perToken))
+	}
+
+	result := RateLimitResult{Limit: l}
+
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) * float64(perToken)))
+	}
+
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration(math.Ceil((capacity - tokens) * float64(perToken)))
+
+	return RateBucket{Tokens: tokens, UpdatedAt: now}, result
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitTake(t *testing.T) {
+	limit := RateLimit{Requests: 3, Per: 3 * time.Second}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name string
+		at   time.Duration
+		want RateLimitResult
+	}{
+		{"full bucket", 0, RateLimitResult{Allowed: true, Remaining: 2, Reset: time.Second}},
+		{"second token", 0, RateLimitResult{Allowed: true, Remaining: 1, Reset: 2 * time.Second}},
+		{"last token", 0, RateLimitResult{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+		{"exhausted", 0, RateLimitResult{Remaining: 0, Reset: 3 * time.Second, RetryAfter: time.Second}},
+		{"partly refilled", 500 * time.Millisecond, RateLimitResult{
+			Remaining: 0, Reset: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond,
+		}},
+		{"refilled", time.Second, RateLimitResult{Allowed: true, Remaining: 0, Reset: 3 * time.Second}},
+		{"clock behind", 0, RateLimitResult{Remaining: 0, Reset: 3 * time.Second, RetryAfter: time.Second}},
+		{"idle", time.Hour, RateLimitResult{Allowed: true, Remaining: 2, Reset: time.Second}},
+	}
+
+	var bucket RateBucket
+
+	for _, step := range steps {
+		var got RateLimitResult
+
+		now := start.Add(step.at)
+		bucket, got = limit.Take(bucket, now)
+
+		step.want.Limit = limit
+		if got != step.want {
+			t.Errorf("%s: Take at %v = %+v, want %+v", step.name, step.at, got, step.want)
+		}
+
+		if !bucket.UpdatedAt.Equal(now) {
+			t.Errorf("%s: bucket updated at %v, want %v", step.name, bucket.UpdatedAt, now)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// Actions recorded in the history of a song.
+const (
+	RevisionInsert  = "insert"
+	RevisionUpdate  = "update"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+	RevisionEnrich  = "enrich"
+	RevisionRevert  = "revert"
+	RevisionPurge   = "purge"
+)
+
+// Revision is a change of a song. Rev is the version the change brought the
+// song to. Old is nil when the song appeared, by insertion or restore, New
+// when it was deleted, and both when it was purged from the trash.
+type Revision struct {
+	SongID    int        `json:"song_id"`
+	Rev       int        `json:"rev"`
+	Action    string     `json:"action"`
+	Old       *MusicInfo `json:"old"`
+	New       *MusicInfo `json:"new"`
+	Actor     string     `json:"actor,omitempty"`
+	RequestID string     `json:"request_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Audit tells who makes a change and within which request. It travels in the
+// context down to the repository, which records it with the revisions.
+type Audit struct {
+	Actor     string
+	RequestID string
+}
+
+type auditKey struct{}
+
+// WithAudit returns a copy of ctx that carries audit.
+func WithAudit(ctx context.Context, audit Audit) context.Context {
+	return context.WithValue(ctx, auditKey{}, audit)
+}
+
+// AuditFrom returns the audit carried by ctx, empty when there is none.
+func AuditFrom(ctx context.Context) Audit {
+	audit, _ := ctx.Value(auditKey{}).(Audit)
+	return audit
+}
@@ -0,0 +1,14 @@
+package models
+
+type SearchQuery struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type SearchResult struct {
+	MusicInfo
+	Rank float64 `json:"rank"`
+	// Snippet is a fragment of the lyrics with the matches wrapped in <b></b>.
+	Snippet string `json:"snippet"`
+}
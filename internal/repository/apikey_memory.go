@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"music/internal/models"
+	"sync"
+	"time"
+)
+
+type apiKeyMemory struct {
+	mu     sync.Mutex
+	keys   []models.APIKey
+	hashes map[string]int
+}
+
+func newAPIKeyMemory() *apiKeyMemory {
+	return &apiKeyMemory{hashes: make(map[string]int)}
+}
+
+func (r *apiKeyMemory) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]models.APIKey, 0, len(r.keys))
+
+	for _, key := range r.keys {
+		result = append(result, copyAPIKey(key))
+	}
+
+	return result, nil
+}
+
+func (r *apiKeyMemory) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ID, ok := r.hashes[hash]
+	if !ok || r.keys[ID-1].RevokedAt != nil {
+		return models.APIKey{}, fmt.Errorf("%w: API key", models.ErrNotFound)
+	}
+
+	return copyAPIKey(r.keys[ID-1]), nil
+}
+
+func (r *apiKeyMemory) AddAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.hashes[hash]; ok {
+		return models.APIKey{}, fmt.Errorf("%w: API key already exists", models.ErrConflict)
+	}
+
+	for _, stored := range r.keys {
+		if stored.Name == key.Name && stored.RevokedAt == nil {
+			return models.APIKey{}, fmt.Errorf("%w: API key %q already exists", models.ErrConflict, key.Name)
+		}
+	}
+
+	stored := models.APIKey{ID: len(r.keys) + 1, Name: key.Name, Role: key.Role, CreatedAt: time.Now()}
+
+	r.keys = append(r.keys, stored)
+	r.hashes[hash] = stored.ID
+
+	return stored, nil
+}
+
+func (r *apiKeyMemory) RevokeAPIKey(ctx context.Context, ID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ID < 1 || ID > len(r.keys) {
+		return fmt.Errorf("%w: API key %d", models.ErrNotFound, ID)
+	}
+
+	if r.keys[ID-1].RevokedAt == nil {
+		now := time.Now()
+		r.keys[ID-1].RevokedAt = &now
+	}
+
+	return nil
+}
+
+// copyAPIKey keeps callers from changing the stored revocation time.
+func copyAPIKey(key models.APIKey) models.APIKey {
+	if key.RevokedAt != nil {
+		revokedAt := *key.RevokedAt
+		key.RevokedAt = &revokedAt
+	}
+
+	return key
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/internal/models"
+)
+
+type APIKey interface {
+	// GetAPIKeys returns all keys, revoked ones included, without their hash.
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// GetAPIKeyByHash returns the key in use with hash. Unknown and revoked
+	// keys are not found.
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	// AddAPIKey stores key under hash. A key in use with the same name is a
+	// conflict.
+	AddAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error)
+	// RevokeAPIKey stops a key from being used. Revoking it again does
+	// nothing.
+	RevokeAPIKey(ctx context.Context, ID int) error
+}
+
+const apiKeyColumns = "id, name, role, created_at, revoked_at"
+
+type apiKeyPostgres struct {
+	db *sql.DB
+}
+
+func newAPIKeyPostgres(db *sql.DB) APIKey {
+	return &apiKeyPostgres{db: db}
+}
+
+func (r *apiKeyPostgres) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.APIKey
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *apiKeyPostgres) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL;"
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, fmt.Errorf("%w: API key", models.ErrNotFound)
+	}
+
+	return key, err
+}
+
+func (r *apiKeyPostgres) AddAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (name, key_hash, role)
+		VALUES ($1, $2, $3)
+		RETURNING ` + apiKeyColumns + `;
+	`
+
+	stored, err := scanAPIKey(r.db.QueryRowContext(ctx, query, key.Name, hash, key.Role))
+	if err != nil {
+		return stored, wrapPostgresError(err)
+	}
+
+	return stored, nil
+}
+
+func (r *apiKeyPostgres) RevokeAPIKey(ctx context.Context, ID int) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1;", ID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: API key %d", models.ErrNotFound, ID)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var (
+		key       models.APIKey
+		revokedAt sql.NullTime
+	)
+
+	if err := row.Scan(&key.ID, &key.Name, &key.Role, &key.CreatedAt, &revokedAt); err != nil {
+		return key, fmt.Errorf("failed to scan API key: %w", err)
+	}
+
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
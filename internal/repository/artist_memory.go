@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"music/internal/models"
+	"strings"
+)
+
+func (r *musicMemory) GetArtists(ctx context.Context, limit, offset int) ([]models.Artist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.Artist
+
+	for _, artist := range r.artists[min(offset, len(r.artists)):] {
+		if len(result) == limit {
+			break
+		}
+
+		result = append(result, artist)
+	}
+
+	return result, nil
+}
+
+func (r *musicMemory) GetArtist(ctx context.Context, ID int) (models.Artist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.artistIndexOf(ID)
+	if i < 0 {
+		return models.Artist{}, fmt.Errorf("%w: artist %d", models.ErrNotFound, ID)
+	}
+
+	return r.artists[i], nil
+}
+
+func (r *musicMemory) GetArtistSongs(ctx context.Context, ID, limit, offset int) ([]models.MusicInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.artistIndexOf(ID)
+	if i < 0 {
+		return nil, fmt.Errorf("%w: artist %d", models.ErrNotFound, ID)
+	}
+
+	var result []models.MusicInfo
+
+	for _, music := range r.musics {
+		if music.deleted() || music.Group != r.artists[i].Name {
+			continue
+		}
+
+		if offset > 0 {
+			offset--
+			continue
+		}
+
+		if len(result) == limit {
+			break
+		}
+
+		result = append(result, music.MusicInfo)
+	}
+
+	return result, nil
+}
+
+func (r *musicMemory) AddArtist(ctx context.Context, name string) (models.Artist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.artistIndexOfName(name) >= 0 {
+		return models.Artist{}, fmt.Errorf("%w: artist %q already exists", models.ErrConflict, name)
+	}
+
+	return r.upsertArtist(name), nil
+}
+
+func (r *musicMemory) RenameArtist(ctx context.Context, ID int, name string) (models.Artist, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.artistIndexOf(ID)
+	if i < 0 {
+		return models.Artist{}, fmt.Errorf("%w: artist %d", models.ErrNotFound, ID)
+	}
+
+	oldName := r.artists[i].Name
+
+	target := r.artistIndexOfName(name)
+	if target < 0 || target == i {
+		r.artists[i].Name = name
+		r.renameSongs(ctx, oldName, name)
+
+		return r.artists[i], nil
+	}
+
+	merged := r.artists[target]
+
+	for _, music := range r.musics {
+		if !music.deleted() && music.Group == oldName && r.songIndexOf(merged.Name, music.Song) >= 0 {
+			return models.Artist{}, fmt.Errorf("%w: artist %d already has song %q", models.ErrConflict, merged.ID, music.Song)
+		}
+	}
+
+	r.renameSongs(ctx, oldName, merged.Name)
+	r.artists = append(r.artists[:i], r.artists[i+1:]...)
+
+	return merged, nil
+}
+
+func (r *musicMemory) DeleteArtist(ctx context.Context, ID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.artistIndexOf(ID)
+	if i < 0 {
+		return fmt.Errorf("%w: artist %d", models.ErrNotFound, ID)
+	}
+
+	for _, music := range r.musics {
+		if music.Group == r.artists[i].Name {
+			return fmt.Errorf("%w: artist %d still has songs", models.ErrConflict, ID)
+		}
+	}
+
+	r.artists = append(r.artists[:i], r.artists[i+1:]...)
+
+	return nil
+}
+
+// renameSongs moves the songs of one artist name to another and records the
+// change in their history. Callers must hold r.mu for writing.
+func (r *musicMemory) renameSongs(ctx context.Context, from, to string) {
+	for j := range r.musics {
+		if r.musics[j].Group == from {
+			old := r.musics[j].MusicInfo
+			r.musics[j].Group = to
+			r.musics[j].Version++
+			r.record(ctx, models.RevisionUpdate, &old, &r.musics[j].MusicInfo)
+		}
+	}
+}
+
+// upsertArtist returns the artist with the given name, creating it when
+// needed. Callers must hold r.mu for writing.
+func (r *musicMemory) upsertArtist(name string) models.Artist {
+	if i := r.artistIndexOfName(name); i >= 0 {
+		return r.artists[i]
+	}
+
+	artist := models.Artist{ID: r.nextArtistID, Name: name}
+	r.nextArtistID++
+
+	r.artists = append(r.artists, artist)
+
+	return artist
+}
+
+// artistIndexOf returns the position of the artist with the given ID or -1.
+// Callers must hold r.mu.
+func (r *musicMemory) artistIndexOf(ID int) int {
+	for i, artist := range r.artists {
+		if artist.ID == ID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (r *musicMemory) artistIndexOfName(name string) int {
+	for i, artist := range r.artists {
+		if strings.EqualFold(artist.Name, name) {
+			return i
+		}
+	}
+
+	return -1
+}
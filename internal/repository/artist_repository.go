@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/internal/models"
+)
+
+type Artist interface {
+	GetArtists(ctx context.Context, limit, offset int) ([]models.Artist, error)
+	GetArtist(ctx context.Context, ID int) (models.Artist, error)
+	GetArtistSongs(ctx context.Context, ID, limit, offset int) ([]models.MusicInfo, error)
+	AddArtist(ctx context.Context, name string) (models.Artist, error)
+	// RenameArtist renames the artist and so all of its songs. When another
+	// artist already has the name ignoring case, the songs are merged into
+	// that artist, unless both have a song with the same title.
+	RenameArtist(ctx context.Context, ID int, name string) (models.Artist, error)
+	DeleteArtist(ctx context.Context, ID int) error
+}
+
+type artistPostgres struct {
+	db *sql.DB
+}
+
+func newArtistPostgres(db *sql.DB) Artist {
+	return &artistPostgres{db: db}
+}
+
+func (r *artistPostgres) GetArtists(ctx context.Context, limit, offset int) ([]models.Artist, error) {
+	query := "SELECT id, name FROM artists ORDER BY id LIMIT $1 OFFSET $2;"
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.Artist
+
+	for rows.Next() {
+		var artist models.Artist
+
+		if err := rows.Scan(&artist.ID, &artist.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		result = append(result, artist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *artistPostgres) GetArtist(ctx context.Context, ID int) (models.Artist, error) {
+	return getArtist(ctx, r.db, ID, "SELECT id, name FROM artists WHERE id = $1;")
+}
+
+func (r *artistPostgres) GetArtistSongs(ctx context.Context, ID, limit, offset int) ([]models.MusicInfo, error) {
+	if _, err := r.GetArtist(ctx, ID); err != nil {
+		return nil, err
+	}
+
+	query := selectMusics + `
+		WHERE s.artist_id = $1 AND s.deleted_at IS NULL
+		ORDER BY s.id
+		LIMIT $2
+		OFFSET $3;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return scanMusics(rows)
+}
+
+func (r *artistPostgres) AddArtist(ctx context.Context, name string) (models.Artist, error) {
+	artist := models.Artist{Name: name}
+
+	err := r.db.QueryRowContext(ctx, "INSERT INTO artists (name) VALUES ($1) RETURNING id;", name).Scan(&artist.ID)
+	if err != nil {
+		return artist, wrapPostgresError(err)
+	}
+
+	return artist, nil
+}
+
+func (r *artistPostgres) RenameArtist(ctx context.Context, ID int, name string) (models.Artist, error) {
+	artist := models.Artist{Name: name}
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := getArtist(ctx, tx, ID, "SELECT id, name FROM artists WHERE id = $1 FOR UPDATE;"); err != nil {
+			return err
+		}
+
+		// The songs are read with the artist name, so they change too and
+		// the change is recorded in their history.
+		olds, err := lockArtistSongs(ctx, tx, ID)
+		if err != nil {
+			return err
+		}
+
+		var target models.Artist
+
+		err = tx.QueryRowContext(
+			ctx, "SELECT id, name FROM artists WHERE lower(name) = lower($1) AND id <> $2 FOR UPDATE;", name, ID,
+		).Scan(&target.ID, &target.Name)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			artist.ID = ID
+
+			if _, err := tx.ExecContext(ctx, "UPDATE artists SET name = $2 WHERE id = $1;", ID, name); err != nil {
+				return wrapPostgresError(err)
+			}
+
+			if _, err := tx.ExecContext(ctx, "UPDATE songs SET version = version + 1 WHERE artist_id = $1;", ID); err != nil {
+				return err
+			}
+		case err != nil:
+			return fmt.Errorf("failed to fetch artist: %w", err)
+		default:
+			// Songs the target already has make the merge fail with a conflict.
+			artist = target
+
+			_, err := tx.ExecContext(
+				ctx, "UPDATE songs SET artist_id = $1, version = version + 1 WHERE artist_id = $2;", target.ID, ID,
+			)
+			if err != nil {
+				return wrapPostgresError(err)
+			}
+
+			if _, err := tx.ExecContext(ctx, "DELETE FROM artists WHERE id = $1;", ID); err != nil {
+				return err
+			}
+		}
+
+		return insertUpdateRevisions(ctx, tx, olds)
+	})
+	if err != nil {
+		return models.Artist{}, err
+	}
+
+	return artist, nil
+}
+
+func (r *artistPostgres) DeleteArtist(ctx context.Context, ID int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM artists WHERE id = $1;", ID)
+	if err != nil {
+		return wrapPostgresError(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: artist %d", models.ErrNotFound, ID)
+	}
+
+	return nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func getArtist(ctx context.Context, db queryRower, ID int, query string) (models.Artist, error) {
+	var artist models.Artist
+
+	if err := db.QueryRowContext(ctx, query, ID).Scan(&artist.ID, &artist.Name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return artist, fmt.Errorf("%w: artist %d", models.ErrNotFound, ID)
+		}
+
+		return artist, fmt.Errorf("failed to fetch artist: %w", err)
+	}
+
+	return artist, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"music/internal/models"
+	"sort"
+	"time"
+)
+
+func (r *musicMemory) ClaimPendingEnrichments(
+	ctx context.Context, limit int, lease time.Duration,
+) ([]models.EnrichmentTask, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var due []*memoryMusic
+
+	for i := range r.musics {
+		music := &r.musics[i]
+		if !music.deleted() && music.EnrichmentStatus == models.EnrichmentPending && !music.enrichmentNextAt.After(now) {
+			due = append(due, music)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].enrichmentNextAt.Before(due[j].enrichmentNextAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	var result []models.EnrichmentTask
+
+	for _, music := range due {
+		music.enrichmentNextAt = now.Add(lease)
+
+		result = append(result, models.EnrichmentTask{
+			ID:       music.ID,
+			Group:    music.Group,
+			Song:     music.Song,
+			Attempts: music.enrichmentAttempts,
+		})
+	}
+
+	return result, nil
+}
+
+func (r *musicMemory) GetEnrichmentStatus(ctx context.Context, ID int) (models.EnrichmentStatus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.indexOf(ID)
+	if i < 0 {
+		return models.EnrichmentStatus{}, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	music := r.musics[i]
+
+	status := models.EnrichmentStatus{
+		ID:       music.ID,
+		Status:   music.EnrichmentStatus,
+		Attempts: music.enrichmentAttempts,
+		Error:    music.enrichmentError,
+	}
+
+	if music.EnrichmentStatus == models.EnrichmentPending {
+		nextAt := music.enrichmentNextAt
+		status.NextAt = &nextAt
+	}
+
+	if !music.enrichedAt.IsZero() {
+		enrichedAt := music.enrichedAt
+		status.EnrichedAt = &enrichedAt
+	}
+
+	return status, nil
+}
+
+func (r *musicMemory) CompleteEnrichment(ctx context.Context, ID int, details models.MusicInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(ID)
+	if i < 0 {
+		return fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	music := &r.musics[i]
+	old := music.MusicInfo
+
+	filled := fillEmpty(music.MusicInfo, details)
+	music.RelaseDate = filled.RelaseDate
+	music.Text = filled.Text
+	music.Link = filled.Link
+	music.EnrichmentStatus = models.EnrichmentEnriched
+	music.enrichmentAttempts++
+	music.enrichmentError = ""
+	music.enrichedAt = time.Now()
+	music.Version++
+
+	r.record(ctx, models.RevisionEnrich, &old, &music.MusicInfo)
+
+	return nil
+}
+
+func (r *musicMemory) FailEnrichment(ctx context.Context, ID int, reason string, retryAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(ID)
+	if i < 0 {
+		return fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	music := &r.musics[i]
+	music.enrichmentAttempts++
+	music.enrichmentError = reason
+
+	if !retryAt.IsZero() {
+		music.enrichmentNextAt = retryAt
+		return nil
+	}
+
+	old := music.MusicInfo
+
+	music.EnrichmentStatus = models.EnrichmentFailed
+	music.Version++
+
+	r.record(ctx, models.RevisionEnrich, &old, &music.MusicInfo)
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"music/internal/models"
+	"time"
+)
+
+type Enrichment interface {
+	// ClaimPendingEnrichments returns up to limit songs due for enrichment,
+	// the longest waiting first, and puts their next attempt off by lease so
+	// that other pollers skip them meanwhile. A song whose enrichment is not
+	// recorded within the lease is due again.
+	ClaimPendingEnrichments(ctx context.Context, limit int, lease time.Duration) ([]models.EnrichmentTask, error)
+	GetEnrichmentStatus(ctx context.Context, ID int) (models.EnrichmentStatus, error)
+	// CompleteEnrichment stores the details found for the song, which is
+	// recorded in its history as models.RevisionEnrich. Only the release
+	// date, lyrics and link the song still lacks are filled in, those set
+	// while it was pending are kept.
+	CompleteEnrichment(ctx context.Context, ID int, details models.MusicInfo) error
+	// FailEnrichment records a failed attempt. The song is retried at retryAt,
+	// or marked as failed for good when retryAt is zero, which is recorded in
+	// its history as models.RevisionEnrich. Retries leave the version alone.
+	FailEnrichment(ctx context.Context, ID int, reason string, retryAt time.Time) error
+}
+
+type enrichmentPostgres struct {
+	db *sql.DB
+}
+
+func newEnrichmentPostgres(db *sql.DB) Enrichment {
+	return &enrichmentPostgres{db: db}
+}
+
+func (r *enrichmentPostgres) ClaimPendingEnrichments(
+	ctx context.Context, limit int, lease time.Duration,
+) ([]models.EnrichmentTask, error) {
+	// Rows claimed by a concurrent poller are skipped rather than waited for.
+	query := `
+		WITH due AS (
+			SELECT id, enrichment_next_at
+			FROM songs
+			WHERE enrichment_status = 'pending' AND enrichment_next_at <= now() AND deleted_at IS NULL
+			ORDER BY enrichment_next_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE songs s
+			SET enrichment_next_at = now() + $2 * INTERVAL '1 millisecond'
+			FROM due
+			WHERE s.id = due.id
+			RETURNING s.id, s.artist_id, s.song, s.enrichment_attempts, due.enrichment_next_at
+		)
+		SELECT c.id, a.name, c.song, c.enrichment_attempts
+		FROM claimed c
+		JOIN artists a ON a.id = c.artist_id
+		ORDER BY c.enrichment_next_at, c.id;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.EnrichmentTask
+
+	for rows.Next() {
+		var task models.EnrichmentTask
+
+		if err := rows.Scan(&task.ID, &task.Group, &task.Song, &task.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		result = append(result, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *enrichmentPostgres) GetEnrichmentStatus(ctx context.Context, ID int) (models.EnrichmentStatus, error) {
+	query := `
+		SELECT id, enrichment_status, enrichment_attempts, enrichment_error, enrichment_next_at, enriched_at
+		FROM songs
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	var (
+		status     models.EnrichmentStatus
+		nextAt     time.Time
+		enrichedAt sql.NullTime
+	)
+
+	err := r.db.QueryRowContext(ctx, query, ID).Scan(
+		&status.ID,
+		&status.Status,
+		&status.Attempts,
+		&status.Error,
+		&nextAt,
+		&enrichedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+		}
+
+		return status, fmt.Errorf("failed to fetch enrichment status: %w", err)
+	}
+
+	if status.Status == models.EnrichmentPending {
+		status.NextAt = &nextAt
+	}
+
+	if enrichedAt.Valid {
+		status.EnrichedAt = &enrichedAt.Time
+	}
+
+	return status, nil
+}
+
+func (r *enrichmentPostgres) CompleteEnrichment(ctx context.Context, ID int, details models.MusicInfo) error {
+	query := `
+		UPDATE songs
+		SET release_date = $2,
+		    link = $3,
+		    enrichment_status = 'enriched',
+		    enrichment_attempts = enrichment_attempts + 1,
+		    enrichment_error = '',
+		    enriched_at = now(),
+		    version = version + 1
+		WHERE id = $1;
+	`
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		old, err := lockMusic(ctx, tx, ID, 0)
+		if err != nil {
+			return err
+		}
+
+		filled := fillEmpty(old, details)
+
+		if _, err := tx.ExecContext(ctx, query, ID, filled.RelaseDate, filled.Link); err != nil {
+			return fmt.Errorf("failed to complete enrichment: %w", err)
+		}
+
+		if old.Text == "" {
+			if err := replaceVerses(ctx, tx, ID, filled.Text); err != nil {
+				return err
+			}
+		}
+
+		music, err := getMusic(ctx, tx, ID)
+		if err != nil {
+			return err
+		}
+
+		return insertRevisions(ctx, tx, []models.Revision{
+			{SongID: ID, Rev: music.Version, Action: models.RevisionEnrich, Old: &old, New: &music},
+		})
+	})
+}
+
+func (r *enrichmentPostgres) FailEnrichment(ctx context.Context, ID int, reason string, retryAt time.Time) error {
+	if !retryAt.IsZero() {
+		query := `
+			UPDATE songs
+			SET enrichment_attempts = enrichment_attempts + 1,
+			    enrichment_error = $2,
+			    enrichment_next_at = $3
+			WHERE id = $1 AND deleted_at IS NULL;
+		`
+
+		res, err := r.db.ExecContext(ctx, query, ID, reason, retryAt)
+		if err != nil {
+			return fmt.Errorf("failed to record enrichment failure: %w", err)
+		}
+
+		return checkAffected(res, ID)
+	}
+
+	query := `
+		UPDATE songs
+		SET enrichment_status = 'failed',
+		    enrichment_attempts = enrichment_attempts + 1,
+		    enrichment_error = $2,
+		    version = version + 1
+		WHERE id = $1;
+	`
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		old, err := lockMusic(ctx, tx, ID, 0)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, ID, reason); err != nil {
+			return fmt.Errorf("failed to record enrichment failure: %w", err)
+		}
+
+		music, err := getMusic(ctx, tx, ID)
+		if err != nil {
+			return err
+		}
+
+		return insertRevisions(ctx, tx, []models.Revision{
+			{SongID: ID, Rev: music.Version, Action: models.RevisionEnrich, Old: &old, New: &music},
+		})
+	})
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"music/internal/models"
+	"sync"
+	"time"
+)
+
+type idempotencyMemory struct {
+	mu   sync.Mutex
+	keys map[string]memoryIdempotency
+}
+
+type memoryIdempotency struct {
+	models.IdempotencyRecord
+
+	expiresAt time.Time
+}
+
+func newIdempotencyMemory() *idempotencyMemory {
+	return &idempotencyMemory{keys: make(map[string]memoryIdempotency)}
+}
+
+func (r *idempotencyMemory) ReserveIdempotencyKey(
+	ctx context.Context, key, fingerprint string, ttl time.Duration,
+) (models.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for k, stored := range r.keys {
+		if stored.expiresAt.Before(now) {
+			delete(r.keys, k)
+		}
+	}
+
+	if stored, ok := r.keys[key]; ok {
+		return stored.IdempotencyRecord, false, nil
+	}
+
+	r.keys[key] = memoryIdempotency{
+		IdempotencyRecord: models.IdempotencyRecord{Fingerprint: fingerprint},
+		expiresAt:         now.Add(ttl),
+	}
+
+	return models.IdempotencyRecord{}, true, nil
+}
+
+func (r *idempotencyMemory) CompleteIdempotencyKey(
+	ctx context.Context, key string, record models.IdempotencyRecord,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[key]
+	if !ok {
+		return nil
+	}
+
+	if record.Fingerprint == "" {
+		record.Fingerprint = stored.Fingerprint
+	}
+	record.Completed = true
+	stored.IdempotencyRecord = record
+	r.keys[key] = stored
+
+	return nil
+}
+
+func (r *idempotencyMemory) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, key)
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music/internal/models"
+	"time"
+)
+
+type Idempotency interface {
+	// ReserveIdempotencyKey claims key for a new request until ttl expires.
+	// When the key is already claimed, its record is returned with
+	// reserved == false.
+	ReserveIdempotencyKey(
+		ctx context.Context, key, fingerprint string, ttl time.Duration,
+	) (record models.IdempotencyRecord, reserved bool, err error)
+	// CompleteIdempotencyKey stores the response of the request of key and,
+	// unless empty, its final fingerprint.
+	CompleteIdempotencyKey(ctx context.Context, key string, record models.IdempotencyRecord) error
+	// ReleaseIdempotencyKey frees key so that the request can be retried.
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
+
+// reserveAttempts bounds the retries of a reservation whose key is released
+// between the insert that found it and the select that reads it.
+const reserveAttempts = 3
+
+type idempotencyPostgres struct {
+	db *sql.DB
+}
+
+func newIdempotencyPostgres(db *sql.DB) Idempotency {
+	return &idempotencyPostgres{db: db}
+}
+
+func (r *idempotencyPostgres) ReserveIdempotencyKey(
+	ctx context.Context, key, fingerprint string, ttl time.Duration,
+) (models.IdempotencyRecord, bool, error) {
+	var record models.IdempotencyRecord
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < now();"); err != nil {
+		return record, false, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	for range reserveAttempts {
+		record, reserved, err := r.reserve(ctx, key, fingerprint, ttl)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return record, reserved, err
+		}
+	}
+
+	return record, false, fmt.Errorf("%w: idempotency key was released during every reservation attempt", models.ErrConflict)
+}
+
+// reserve makes one attempt at ReserveIdempotencyKey. It fails with
+// sql.ErrNoRows when the key it could not insert is gone.
+func (r *idempotencyPostgres) reserve(
+	ctx context.Context, key, fingerprint string, ttl time.Duration,
+) (models.IdempotencyRecord, bool, error) {
+	var record models.IdempotencyRecord
+
+	insert := `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, now() + make_interval(secs => $3))
+		ON CONFLICT (key) DO NOTHING;
+	`
+
+	res, err := r.db.ExecContext(ctx, insert, key, fingerprint, ttl.Seconds())
+	if err != nil {
+		return record, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return record, false, err
+	}
+
+	if affected == 1 {
+		return record, true, nil
+	}
+
+	var (
+		status  sql.NullInt64
+		headers []byte
+	)
+
+	err = r.db.QueryRowContext(
+		ctx, "SELECT fingerprint, status, headers, body FROM idempotency_keys WHERE key = $1;", key,
+	).Scan(&record.Fingerprint, &status, &headers, &record.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return record, false, err
+	}
+	if err != nil {
+		return record, false, fmt.Errorf("failed to fetch idempotency key: %w", err)
+	}
+
+	record.Completed = status.Valid
+	record.Status = int(status.Int64)
+
+	if err := json.Unmarshal(headers, &record.Header); err != nil {
+		return record, false, fmt.Errorf("failed to decode stored headers: %w", err)
+	}
+
+	return record, false, nil
+}
+
+func (r *idempotencyPostgres) CompleteIdempotencyKey(
+	ctx context.Context, key string, record models.IdempotencyRecord,
+) error {
+	headers, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET status = $2, headers = $3, body = $4, fingerprint = COALESCE(NULLIF($5, ''), fingerprint)
+		WHERE key = $1;
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, key, record.Status, headers, record.Body, record.Fingerprint); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+func (r *idempotencyPostgres) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1;", key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/repository/repotest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	repotest.TestMusic(t, func(t *testing.T) *repository.Repository {
+		return repository.NewMemoryRepository()
+	})
+}
+
+// TestMemorySortIgnoresCase checks that the memory repository sorts like a
+// case insensitive collation rather than by bytes, under which "muse" would
+// follow "Radiohead".
+func TestMemorySortIgnoresCase(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	for _, music := range []models.MusicInfo{
+		{Group: "Radiohead", Song: "Creep"},
+		{Group: "muse", Song: "uprising"},
+		{Group: "Muse", Song: "Resistance"},
+	} {
+		if _, err := repo.Music.AddMusic(ctx, music); err != nil {
+			t.Fatalf("AddMusic: %v", err)
+		}
+	}
+
+	sort := []models.SortField{{Field: models.SortGroup}, {Field: models.SortSong}}
+
+	got, err := repo.Music.GetMusics(ctx, models.MusicFilter{Sort: sort, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+
+	var songs []string
+	for _, music := range got {
+		songs = append(songs, music.Song)
+	}
+
+	if want := []string{"Resistance", "uprising", "Creep"}; !slices.Equal(songs, want) {
+		t.Errorf("songs sorted by group and song = %q, want %q", songs, want)
+	}
+
+	keys := models.MusicFilter{Sort: sort}.SortKeys()
+	after := models.NewCursor(got[0], keys)
+
+	got, err = repo.Music.GetMusics(ctx, models.MusicFilter{Sort: sort, Limit: 10, After: &after})
+	if err != nil || len(got) != 2 || got[0].Song != "uprising" {
+		t.Errorf("GetMusics after %q = %+v, %v, want uprising and Creep", "Resistance", got, err)
+	}
+}
+
+// TestMemoryConcurrent checks that the memory repository, which has no
+// database to serialize its callers, can be shared by the handlers of
+// concurrent requests. Run it with -race.
+func TestMemoryConcurrent(t *testing.T) {
+	const (
+		workers = 8
+		songs   = 25
+	)
+
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+
+	var wg sync.WaitGroup
+
+	for w := range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range songs {
+				music, err := repo.Music.AddMusic(ctx, models.MusicInfo{
+					Group: fmt.Sprintf("Group %d", w), Song: fmt.Sprintf("Song %d", i),
+				})
+				if err != nil {
+					t.Errorf("AddMusic: %v", err)
+					return
+				}
+
+				_, err = repo.Music.UpdateMusic(ctx, music.ID, models.MusicUpdate{Text: models.Set("verse")}, music.Version)
+				if err != nil {
+					t.Errorf("UpdateMusic(%d): %v", music.ID, err)
+					return
+				}
+
+				if _, err := repo.Music.GetMusics(ctx, models.MusicFilter{Limit: 10}); err != nil {
+					t.Errorf("GetMusics: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	musics, err := repo.Music.GetMusics(ctx, models.MusicFilter{Limit: workers * songs})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+
+	if len(musics) != workers*songs {
+		t.Fatalf("got %d songs, want %d", len(musics), workers*songs)
+	}
+
+	for i, music := range musics {
+		if music.ID != i+1 || music.Version != 2 || music.Text != "verse" {
+			t.Errorf("song %d = %+v, want ID %d at version 2 with its text", i, music, i+1)
+		}
+	}
+
+	artists, err := repo.Artist.GetArtists(ctx, workers+1, 0)
+	if err != nil || len(artists) != workers {
+		t.Errorf("GetArtists = %d artists, %v, want %d", len(artists), err, workers)
+	}
+}
@@ -0,0 +1,577 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"music/internal/models"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// musicMemory keeps songs and artists in slices ordered by ID. It implements
+// Music, Enrichment and Artist on top of the same data.
+type musicMemory struct {
+	mu     sync.RWMutex
+	musics []memoryMusic
+	nextID int
+
+	artists      []models.Artist
+	nextArtistID int
+
+	// revisions holds the history of every song by ID, oldest first.
+	revisions map[int][]models.Revision
+}
+
+type memoryMusic struct {
+	models.MusicInfo
+
+	enrichmentAttempts int
+	enrichmentError    string
+	enrichmentNextAt   time.Time
+	enrichedAt         time.Time
+
+	// deletedAt is set while the song is in the trash.
+	deletedAt time.Time
+}
+
+func (m memoryMusic) deleted() bool {
+	return !m.deletedAt.IsZero()
+}
+
+func newMusicMemory() *musicMemory {
+	return &musicMemory{nextID: 1, nextArtistID: 1, revisions: make(map[int][]models.Revision)}
+}
+
+func (r *musicMemory) GetMusics(ctx context.Context, filter models.MusicFilter) ([]models.MusicInfo, error) {
+	keys := filter.SortKeys()
+
+	var after models.MusicInfo
+
+	if filter.After != nil {
+		if len(filter.After.Values) != len(keys)-1 {
+			return nil, fmt.Errorf("%w: cursor does not match the sort", models.ErrValidation)
+		}
+
+		var err error
+
+		if after, err = cursorMusic(*filter.After, keys); err != nil {
+			return nil, err
+		}
+	}
+
+	matched := r.matchMusics(filter, keys)
+
+	offset := filter.Offset
+	if filter.After != nil {
+		offset = slices.IndexFunc(matched, func(music models.MusicInfo) bool {
+			return compareMusics(music, after, keys) > 0
+		})
+		if offset < 0 {
+			offset = len(matched)
+		}
+	}
+
+	offset = min(max(offset, 0), len(matched))
+	matched = matched[offset:]
+
+	if len(matched) > filter.Limit {
+		matched = matched[:max(filter.Limit, 0)]
+	}
+
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	return matched, nil
+}
+
+func (r *musicMemory) ExportMusics(
+	ctx context.Context, filter models.MusicFilter, yield func(models.MusicInfo) error,
+) error {
+	for _, music := range r.matchMusics(filter, filter.SortKeys()) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := yield(music); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchMusics returns a copy of the songs that match filter, ordered by keys.
+// Pagination is left to the caller.
+func (r *musicMemory) matchMusics(filter models.MusicFilter, keys []models.SortField) []models.MusicInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []models.MusicInfo
+
+	for _, music := range r.musics {
+		if !music.deleted() &&
+			matchText(music.Group, filter.Group) &&
+			matchText(music.Song, filter.Song) &&
+			matchText(music.Text, filter.Text) &&
+			matchDate(music.RelaseDate, filter) {
+			matched = append(matched, music.MusicInfo)
+		}
+	}
+
+	slices.SortStableFunc(matched, func(a, b models.MusicInfo) int {
+		return compareMusics(a, b, keys)
+	})
+
+	return matched
+}
+
+func (r *musicMemory) GetMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.indexOf(ID)
+	if i < 0 {
+		return models.MusicInfo{}, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	return r.musics[i].MusicInfo, nil
+}
+
+func (r *musicMemory) GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) (models.Lyrics, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lyrics := models.Lyrics{Couplet: couplet, Size: size}
+
+	i := r.indexOf(ID)
+	if i < 0 {
+		return lyrics, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	lyrics.Verses, lyrics.TotalVerses = paginateVerses(r.musics[i].Text, couplet, size)
+
+	return lyrics, nil
+}
+
+func (r *musicMemory) AddMusic(ctx context.Context, music models.MusicInfo) (models.MusicInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.songIndexOf(music.Group, music.Song); i >= 0 {
+		return models.MusicInfo{}, &models.SongExistsError{ID: r.musics[i].ID}
+	}
+
+	r.addMusic(music)
+
+	stored := r.musics[len(r.musics)-1].MusicInfo
+	r.record(ctx, models.RevisionInsert, nil, &stored)
+
+	return stored, nil
+}
+
+func (r *musicMemory) AddMusics(ctx context.Context, musics []models.MusicInfo) ([]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	IDs := make([]int, len(musics))
+
+	for i, music := range musics {
+		if r.songIndexOf(music.Group, music.Song) < 0 {
+			IDs[i] = r.addMusic(music)
+			r.record(ctx, models.RevisionInsert, nil, &r.musics[len(r.musics)-1].MusicInfo)
+		}
+	}
+
+	return IDs, nil
+}
+
+// addMusic stores music and returns its ID. Callers must hold r.mu.
+func (r *musicMemory) addMusic(music models.MusicInfo) int {
+	music.ID = r.nextID
+	music.Version = 1
+	r.nextID++
+
+	music.Group = r.upsertArtist(music.Group).Name
+	music.Text = joinVerses(music.Text)
+
+	if music.EnrichmentStatus == "" {
+		music.EnrichmentStatus = models.EnrichmentPending
+	}
+
+	stored := memoryMusic{MusicInfo: music, enrichmentNextAt: time.Now()}
+	if music.EnrichmentStatus == models.EnrichmentEnriched {
+		stored.enrichedAt = time.Now()
+	}
+
+	r.musics = append(r.musics, stored)
+
+	return music.ID
+}
+
+func (r *musicMemory) UpdateMusic(
+	ctx context.Context, ID int, updates models.MusicUpdate, version int,
+) (models.MusicInfo, error) {
+	if err := checkUpdate(updates); err != nil {
+		return models.MusicInfo{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(ID)
+	if i < 0 {
+		return models.MusicInfo{}, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	if err := r.checkVersion(i, version); err != nil {
+		return models.MusicInfo{}, err
+	}
+
+	music := &r.musics[i]
+	old := music.MusicInfo
+
+	group, song := music.Group, music.Song
+	if updates.Group.Set {
+		group = updates.Group.Value
+	}
+	if updates.Song.Set {
+		song = updates.Song.Value
+	}
+
+	if j := r.songIndexOf(group, song); j >= 0 && j != i {
+		return models.MusicInfo{}, fmt.Errorf("%w: song already exists with id %d", models.ErrConflict, r.musics[j].ID)
+	}
+
+	if updates.Group.Set {
+		music.Group = r.upsertArtist(updates.Group.Value).Name
+	}
+	if updates.Song.Set {
+		music.Song = updates.Song.Value
+	}
+	if updates.RelaseDate.Set {
+		music.RelaseDate = updates.RelaseDate.Value
+	}
+	if updates.Text.Set {
+		music.Text = joinVerses(updates.Text.Value)
+	}
+	if updates.Link.Set {
+		music.Link = updates.Link.Value
+	}
+
+	music.Version++
+
+	r.record(ctx, models.RevisionUpdate, &old, &music.MusicInfo)
+
+	return music.MusicInfo, nil
+}
+
+func (r *musicMemory) DeleteMusic(ctx context.Context, ID, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(ID)
+	if i < 0 {
+		return fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	if err := r.checkVersion(i, version); err != nil {
+		return err
+	}
+
+	old := r.musics[i].MusicInfo
+
+	r.musics[i].deletedAt = time.Now()
+	r.musics[i].Version++
+
+	r.record(ctx, models.RevisionDelete, &old, nil)
+
+	return nil
+}
+
+func (r *musicMemory) GetDeletedMusics(ctx context.Context, limit, offset int) ([]models.DeletedMusic, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deleted []models.DeletedMusic
+
+	for _, music := range r.musics {
+		if music.deleted() {
+			deleted = append(deleted, models.DeletedMusic{MusicInfo: music.MusicInfo, DeletedAt: music.deletedAt})
+		}
+	}
+
+	slices.SortStableFunc(deleted, func(a, b models.DeletedMusic) int {
+		return cmp.Or(b.DeletedAt.Compare(a.DeletedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	offset = min(max(offset, 0), len(deleted))
+	deleted = deleted[offset:]
+
+	if len(deleted) > limit {
+		deleted = deleted[:max(limit, 0)]
+	}
+
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+
+	return deleted, nil
+}
+
+func (r *musicMemory) RestoreMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.musics, func(music memoryMusic) bool {
+		return music.ID == ID && music.deleted()
+	})
+	if i < 0 {
+		return models.MusicInfo{}, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	music := &r.musics[i]
+
+	if j := r.songIndexOf(music.Group, music.Song); j >= 0 {
+		return models.MusicInfo{}, fmt.Errorf("%w: song already exists with id %d", models.ErrConflict, r.musics[j].ID)
+	}
+
+	music.deletedAt = time.Time{}
+	music.Version++
+
+	r.record(ctx, models.RevisionRestore, nil, &music.MusicInfo)
+
+	return music.MusicInfo, nil
+}
+
+func (r *musicMemory) PurgeMusics(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.musics)
+	audit := models.AuditFrom(ctx)
+
+	r.musics = slices.DeleteFunc(r.musics, func(music memoryMusic) bool {
+		purged := music.deleted() && music.deletedAt.Before(before)
+		if purged {
+			r.revisions[music.ID] = append(r.revisions[music.ID], models.Revision{
+				SongID:    music.ID,
+				Rev:       music.Version + 1,
+				Action:    models.RevisionPurge,
+				Actor:     audit.Actor,
+				RequestID: audit.RequestID,
+				CreatedAt: time.Now(),
+			})
+		}
+
+		return purged
+	})
+
+	return n - len(r.musics), nil
+}
+
+func (r *musicMemory) GetMusicHistory(ctx context.Context, ID, limit, offset int) ([]models.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Purged songs are only left in the history.
+	_, recorded := r.revisions[ID]
+	if !recorded && !slices.ContainsFunc(r.musics, func(music memoryMusic) bool { return music.ID == ID }) {
+		return nil, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	history := slices.Clone(r.revisions[ID])
+	slices.Reverse(history)
+
+	offset = min(max(offset, 0), len(history))
+	history = history[offset:]
+
+	if len(history) > limit {
+		history = history[:max(limit, 0)]
+	}
+
+	if len(history) == 0 {
+		return nil, nil
+	}
+
+	return history, nil
+}
+
+func (r *musicMemory) RevertMusic(ctx context.Context, ID, rev, version int) (models.MusicInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(ID)
+	if i < 0 {
+		return models.MusicInfo{}, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	if err := r.checkVersion(i, version); err != nil {
+		return models.MusicInfo{}, err
+	}
+
+	j := slices.IndexFunc(r.revisions[ID], func(revision models.Revision) bool { return revision.Rev == rev })
+	if j < 0 {
+		return models.MusicInfo{}, fmt.Errorf("%w: revision %d of song %d", models.ErrNotFound, rev, ID)
+	}
+
+	target := r.revisions[ID][j].New
+	if target == nil {
+		return models.MusicInfo{}, fmt.Errorf("%w: revision %d of song %d deleted it", models.ErrValidation, rev, ID)
+	}
+
+	if k := r.songIndexOf(target.Group, target.Song); k >= 0 && k != i {
+		return models.MusicInfo{}, fmt.Errorf("%w: song already exists with id %d", models.ErrConflict, r.musics[k].ID)
+	}
+
+	music := &r.musics[i]
+	old := music.MusicInfo
+
+	music.Group = r.upsertArtist(target.Group).Name
+	music.Song = target.Song
+	music.RelaseDate = target.RelaseDate
+	music.Text = target.Text
+	music.Link = target.Link
+	music.Version++
+
+	r.record(ctx, models.RevisionRevert, &old, &music.MusicInfo)
+
+	return music.MusicInfo, nil
+}
+
+// record adds a change of a song to its history, with the audit of ctx. The
+// song before and after the change is copied. Callers must hold r.mu.
+func (r *musicMemory) record(ctx context.Context, action string, before, after *models.MusicInfo) {
+	audit := models.AuditFrom(ctx)
+
+	revision := models.Revision{
+		Action:    action,
+		Actor:     audit.Actor,
+		RequestID: audit.RequestID,
+		CreatedAt: time.Now(),
+	}
+
+	if before != nil {
+		music := *before
+		revision.SongID, revision.Rev, revision.Old = music.ID, music.Version+1, &music
+	}
+
+	if after != nil {
+		music := *after
+		revision.SongID, revision.Rev, revision.New = music.ID, music.Version, &music
+	}
+
+	r.revisions[revision.SongID] = append(r.revisions[revision.SongID], revision)
+}
+
+// indexOf returns the position of the song with the given ID or -1, songs in
+// the trash are left out. Callers must hold r.mu.
+func (r *musicMemory) indexOf(ID int) int {
+	for i, music := range r.musics {
+		if music.ID == ID && !music.deleted() {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// checkVersion fails when version is set and differs from the version of the
+// song at i. Callers must hold r.mu.
+func (r *musicMemory) checkVersion(i, version int) error {
+	if version != 0 && r.musics[i].Version != version {
+		return fmt.Errorf(
+			"%w: song %d is at version %d", models.ErrPreconditionFailed, r.musics[i].ID, r.musics[i].Version,
+		)
+	}
+
+	return nil
+}
+
+// matchText mirrors the text filters of the Postgres implementation. The
+// exact mode compares the whole value ignoring case, the contains mode looks
+// for the value as a substring ignoring case. An empty value matches
+// everything in both modes.
+func matchText(s string, filter models.TextFilter) bool {
+	if filter.Exact() {
+		return filter.Value == "" || strings.EqualFold(s, filter.Value)
+	}
+
+	return containsFold(s, filter.Value)
+}
+
+// matchDate applies the date filters, songs without a date never match a
+// date filter.
+func matchDate(date models.Date, filter models.MusicFilter) bool {
+	if filter.ReleaseDate.IsZero() && filter.ReleasedAfter.IsZero() && filter.ReleasedBefore.IsZero() {
+		return true
+	}
+
+	return !date.IsZero() &&
+		(filter.ReleaseDate.IsZero() || date == filter.ReleaseDate) &&
+		(filter.ReleasedAfter.IsZero() || date.Compare(filter.ReleasedAfter) >= 0) &&
+		(filter.ReleasedBefore.IsZero() || date.Compare(filter.ReleasedBefore) <= 0)
+}
+
+// compareMusics orders songs by keys. Text is compared ignoring case, which
+// is closer to the collation Postgres sorts by than the order of bytes.
+// Values equal but for case are left to the following keys.
+func compareMusics(a, b models.MusicInfo, keys []models.SortField) int {
+	for _, key := range keys {
+		var c int
+
+		if key.Field == models.SortID {
+			c = cmp.Compare(a.ID, b.ID)
+		} else {
+			c = strings.Compare(strings.ToLower(a.SortValue(key.Field)), strings.ToLower(b.SortValue(key.Field)))
+		}
+
+		if key.Desc {
+			c = -c
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// cursorMusic returns a song that sorts at the position of cursor.
+func cursorMusic(cursor models.Cursor, keys []models.SortField) (models.MusicInfo, error) {
+	music := models.MusicInfo{ID: cursor.ID}
+
+	var err error
+
+	for i, key := range keys[:len(keys)-1] {
+		switch key.Field {
+		case models.SortGroup:
+			music.Group = cursor.Values[i]
+		case models.SortSong:
+			music.Song = cursor.Values[i]
+		case models.SortReleaseDate:
+			if music.RelaseDate, err = models.ParseDate(cursor.Values[i]); err != nil {
+				return music, err
+			}
+		}
+	}
+
+	return music, nil
+}
+
+// songIndexOf returns the position of the song with the given group and
+// title ignoring case or -1, songs in the trash are left out. Callers must
+// hold r.mu.
+func (r *musicMemory) songIndexOf(group, song string) int {
+	return slices.IndexFunc(r.musics, func(music memoryMusic) bool {
+		return !music.deleted() && strings.EqualFold(music.Group, group) && strings.EqualFold(music.Song, song)
+	})
+}
+
+func containsFold(s, substr string) bool {
+	return substr == "" || strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
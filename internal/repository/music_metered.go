@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"music/internal/metrics"
+	"music/internal/models"
+	"time"
+)
+
+// meteredMusic records how long every call of the wrapped Music takes.
+type meteredMusic struct {
+	music   Music
+	metrics *metrics.Metrics
+}
+
+// NewMeteredMusic wraps music so that its calls are timed per method in m.
+func NewMeteredMusic(music Music, m *metrics.Metrics) Music {
+	return &meteredMusic{music: music, metrics: m}
+}
+
+func (r *meteredMusic) observe(method string, start time.Time, err error) {
+	r.metrics.ObserveDBQuery(method, queryOutcome(err), time.Since(start))
+}
+
+// queryOutcome classifies the result of a call for the metrics.
+func queryOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case errors.Is(err, models.ErrNotFound):
+		return metrics.OutcomeNotFound
+	case errors.Is(err, models.ErrConflict), errors.Is(err, models.ErrPreconditionFailed):
+		return metrics.OutcomeConflict
+	case errors.Is(err, models.ErrValidation), errors.Is(err, models.ErrNoUpdates), errors.Is(err, models.ErrOutOfRange):
+		return metrics.OutcomeRejected
+	default:
+		return metrics.OutcomeError
+	}
+}
+
+func (r *meteredMusic) GetMusics(ctx context.Context, filter models.MusicFilter) ([]models.MusicInfo, error) {
+	start := time.Now()
+	musics, err := r.music.GetMusics(ctx, filter)
+	r.observe("GetMusics", start, err)
+
+	return musics, err
+}
+
+func (r *meteredMusic) GetMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	start := time.Now()
+	music, err := r.music.GetMusic(ctx, ID)
+	r.observe("GetMusic", start, err)
+
+	return music, err
+}
+
+func (r *meteredMusic) GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) (models.Lyrics, error) {
+	start := time.Now()
+	lyrics, err := r.music.GetSongLyricsByVerses(ctx, ID, couplet, size)
+	r.observe("GetSongLyricsByVerses", start, err)
+
+	return lyrics, err
+}
+
+func (r *meteredMusic) AddMusic(ctx context.Context, music models.MusicInfo) (models.MusicInfo, error) {
+	start := time.Now()
+	music, err := r.music.AddMusic(ctx, music)
+	r.observe("AddMusic", start, err)
+
+	return music, err
+}
+
+func (r *meteredMusic) AddMusics(ctx context.Context, musics []models.MusicInfo) ([]int, error) {
+	start := time.Now()
+	ids, err := r.music.AddMusics(ctx, musics)
+	r.observe("AddMusics", start, err)
+
+	return ids, err
+}
+
+func (r *meteredMusic) UpdateMusic(ctx context.Context, ID int, updates models.MusicUpdate, version int) (models.MusicInfo, error) {
+	start := time.Now()
+	music, err := r.music.UpdateMusic(ctx, ID, updates, version)
+	r.observe("UpdateMusic", start, err)
+
+	return music, err
+}
+
+func (r *meteredMusic) DeleteMusic(ctx context.Context, ID, version int) error {
+	start := time.Now()
+	err := r.music.DeleteMusic(ctx, ID, version)
+	r.observe("DeleteMusic", start, err)
+
+	return err
+}
+
+func (r *meteredMusic) GetDeletedMusics(ctx context.Context, limit, offset int) ([]models.DeletedMusic, error) {
+	start := time.Now()
+	musics, err := r.music.GetDeletedMusics(ctx, limit, offset)
+	r.observe("GetDeletedMusics", start, err)
+
+	return musics, err
+}
+
+func (r *meteredMusic) RestoreMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	start := time.Now()
+	music, err := r.music.RestoreMusic(ctx, ID)
+	r.observe("RestoreMusic", start, err)
+
+	return music, err
+}
+
+func (r *meteredMusic) PurgeMusics(ctx context.Context, before time.Time) (int, error) {
+	start := time.Now()
+	purged, err := r.music.PurgeMusics(ctx, before)
+	r.observe("PurgeMusics", start, err)
+
+	return purged, err
+}
+
+func (r *meteredMusic) GetMusicHistory(ctx context.Context, ID, limit, offset int) ([]models.Revision, error) {
+	start := time.Now()
+	revisions, err := r.music.GetMusicHistory(ctx, ID, limit, offset)
+	r.observe("GetMusicHistory", start, err)
+
+	return revisions, err
+}
+
+func (r *meteredMusic) RevertMusic(ctx context.Context, ID, rev, version int) (models.MusicInfo, error) {
+	start := time.Now()
+	music, err := r.music.RevertMusic(ctx, ID, rev, version)
+	r.observe("RevertMusic", start, err)
+
+	return music, err
+}
+
+// ExportMusics times the whole export, the time spent in yield included.
+func (r *meteredMusic) ExportMusics(ctx context.Context, filter models.MusicFilter, yield func(models.MusicInfo) error) error {
+	start := time.Now()
+	err := r.music.ExportMusics(ctx, filter, yield)
+	r.observe("ExportMusics", start, err)
+
+	return err
+}
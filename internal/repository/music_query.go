@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"fmt"
+	"music/internal/models"
+	"slices"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// sortColumns maps the sort fields to expressions of musicSource. Songs
+// without a release date sort first, as they do in the memory repository.
+var sortColumns = map[string]string{
+	models.SortGroup:       "a.name",
+	models.SortSong:        "s.song",
+	models.SortReleaseDate: "COALESCE(s.release_date, '-infinity'::DATE)",
+	models.SortID:          "s.id",
+}
+
+// sortParams converts a cursor value to the type of its sort column.
+var sortParams = map[string]string{
+	models.SortReleaseDate: "COALESCE(NULLIF(?, '')::DATE, '-infinity'::DATE)",
+}
+
+// buildMusicsQuery selects a page of the songs that match filter.
+func buildMusicsQuery(filter models.MusicFilter) (string, []any, error) {
+	keys := filter.SortKeys()
+
+	query, err := filterMusics(filter, keys)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if filter.After != nil {
+		after, err := afterCursor(keys, *filter.After)
+		if err != nil {
+			return "", nil, err
+		}
+
+		query = query.Where(after)
+	} else {
+		query = query.Offset(uint64(max(filter.Offset, 0)))
+	}
+
+	return query.Limit(uint64(max(filter.Limit, 0))).ToSql()
+}
+
+// filterMusics selects all the songs that match filter, ordered by keys.
+func filterMusics(filter models.MusicFilter, keys []models.SortField) (sq.SelectBuilder, error) {
+	query := psql.Select(musicColumns).From(musicSource).Where("s.deleted_at IS NULL")
+
+	query = whereText(query, "a.name", filter.Group)
+	query = whereText(query, "s.song", filter.Song)
+	query = whereText(query, "l.text", filter.Text)
+
+	if !filter.ReleaseDate.IsZero() {
+		query = query.Where(sq.Eq{"s.release_date": filter.ReleaseDate})
+	}
+	if !filter.ReleasedAfter.IsZero() {
+		query = query.Where(sq.GtOrEq{"s.release_date": filter.ReleasedAfter})
+	}
+	if !filter.ReleasedBefore.IsZero() {
+		query = query.Where(sq.LtOrEq{"s.release_date": filter.ReleasedBefore})
+	}
+
+	for _, key := range keys {
+		column, ok := sortColumns[key.Field]
+		if !ok {
+			return query, fmt.Errorf("%w: unknown sort field %q", models.ErrValidation, key.Field)
+		}
+
+		if key.Desc {
+			column += " DESC"
+		}
+
+		query = query.OrderBy(column)
+	}
+
+	return query, nil
+}
+
+func whereText(query sq.SelectBuilder, column string, filter models.TextFilter) sq.SelectBuilder {
+	switch {
+	case filter.Value == "":
+		return query
+	case filter.Exact():
+		return query.Where(sq.Expr("lower("+column+") = lower(?)", filter.Value))
+	default:
+		return query.Where(sq.Expr(column+" ILIKE ?", "%"+escapeLike(filter.Value)+"%"))
+	}
+}
+
+// afterCursor selects the songs that come after cursor in the order of keys:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+func afterCursor(keys []models.SortField, cursor models.Cursor) (sq.Sqlizer, error) {
+	if len(cursor.Values) != len(keys)-1 {
+		return nil, fmt.Errorf("%w: cursor does not match the sort", models.ErrValidation)
+	}
+
+	var (
+		after sq.Or
+		equal sq.And
+	)
+
+	for i, key := range keys {
+		column, ok := sortColumns[key.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown sort field %q", models.ErrValidation, key.Field)
+		}
+
+		param, ok := sortParams[key.Field]
+		if !ok {
+			param = "?"
+		}
+
+		var value any = cursor.ID
+		if key.Field != models.SortID {
+			value = cursor.Values[i]
+		}
+
+		op := " > "
+		if key.Desc {
+			op = " < "
+		}
+
+		after = append(after, append(slices.Clone(equal), sq.Expr(column+op+param, value)))
+		equal = append(equal, sq.Expr(column+" = "+param, value))
+	}
+
+	return after, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes s match literally in a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -6,16 +6,72 @@ import (
 	"errors"
 	"fmt"
 	"music/internal/models"
-	"reflect"
 	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+// musicColumns and musicSource read songs in the shape of models.MusicInfo,
+// the verses are joined back into a single text with verseSeparator.
+const (
+	musicColumns = `s.id, a.name, s.song, s.release_date, l.text, s.link, s.enrichment_status, s.version`
+	musicSource  = `songs s
+	JOIN artists a ON a.id = s.artist_id
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(string_agg(v.text, E'\n\n' ORDER BY v.position), '') AS text
+		FROM verses v
+		WHERE v.song_id = s.id
+	) l`
 )
 
+const selectMusics = `
+	SELECT ` + musicColumns + `
+	FROM ` + musicSource + `
+`
+
 type Music interface {
-	GetMusics(ctx context.Context, group, song, releaseDate, text string, limit, offset int) ([]models.MusicInfo, error)
-	GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) ([]string, error)
-	AddMusic(ctx context.Context, music models.MusicInfo) error
-	UpdateMusic(ctx context.Context, ID int, updates models.MusicUpdate) error
-	DeleteMusic(ctx context.Context, ID int) error
+	GetMusics(ctx context.Context, filter models.MusicFilter) ([]models.MusicInfo, error)
+	GetMusic(ctx context.Context, ID int) (models.MusicInfo, error)
+	// GetSongLyricsByVerses returns size verses of the song starting from the
+	// couplet-th and the total number of verses. Pages past the last verse
+	// are empty.
+	GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) (models.Lyrics, error)
+	// AddMusic stores music and returns it as stored, with its ID and the
+	// group spelled as the existing artist.
+	AddMusic(ctx context.Context, music models.MusicInfo) (models.MusicInfo, error)
+	// AddMusics stores a batch of songs and returns their IDs in the same
+	// order. Songs whose group and title are already stored, or repeat an
+	// earlier song of the batch, are skipped with ID 0.
+	AddMusics(ctx context.Context, musics []models.MusicInfo) ([]int, error)
+	// UpdateMusic and DeleteMusic with a non-zero version fail with
+	// models.ErrPreconditionFailed unless the song is at that version.
+	UpdateMusic(ctx context.Context, ID int, updates models.MusicUpdate, version int) (models.MusicInfo, error)
+	// DeleteMusic moves the song to the trash. Songs in the trash are left
+	// out of every other method until they are restored.
+	DeleteMusic(ctx context.Context, ID, version int) error
+	// GetDeletedMusics lists the trash, the latest deleted songs first.
+	GetDeletedMusics(ctx context.Context, limit, offset int) ([]models.DeletedMusic, error)
+	// RestoreMusic takes a song out of the trash. It fails with
+	// models.ErrConflict when a song with the same title was added since.
+	RestoreMusic(ctx context.Context, ID int) (models.MusicInfo, error)
+	// PurgeMusics removes the songs deleted before the given time for good
+	// and returns their number. Their history is kept, ending with a
+	// models.RevisionPurge revision.
+	PurgeMusics(ctx context.Context, before time.Time) (int, error)
+	// GetMusicHistory lists the revisions of a song, the latest first. Every
+	// change made through Music and the enrichment of the lyrics is recorded
+	// with the audit of its context. Songs in the trash and purged songs
+	// have a history too.
+	GetMusicHistory(ctx context.Context, ID, limit, offset int) ([]models.Revision, error)
+	// RevertMusic brings the song back to how it was after revision rev, as
+	// a new revision. The enrichment status is left as is. Reverting to a
+	// deletion fails with models.ErrValidation.
+	RevertMusic(ctx context.Context, ID, rev, version int) (models.MusicInfo, error)
+	// ExportMusics calls yield for every song that matches filter, in its
+	// sort order, until yield fails. Pagination fields are ignored.
+	ExportMusics(ctx context.Context, filter models.MusicFilter, yield func(models.MusicInfo) error) error
 }
 
 type musicPostgres struct {
@@ -26,45 +82,359 @@ func newMusicPostgres(db *sql.DB) Music {
 	return &musicPostgres{db: db}
 }
 
-func (r *musicPostgres) GetMusics(
-	ctx context.Context, group, song, releaseDate, text string, limit, offset int,
-) ([]models.MusicInfo, error) {
+func (r *musicPostgres) GetMusics(ctx context.Context, filter models.MusicFilter) ([]models.MusicInfo, error) {
+	query, args, err := buildMusicsQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return scanMusics(rows)
+}
+
+func (r *musicPostgres) GetMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	return getMusic(ctx, r.db, ID)
+}
+
+func (r *musicPostgres) GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) (models.Lyrics, error) {
+	lyrics := models.Lyrics{Verses: []string{}, Couplet: couplet, Size: size}
+
+	query := `
+		SELECT (SELECT count(*) FROM verses v WHERE v.song_id = s.id)
+		FROM songs s
+		WHERE s.id = $1 AND s.deleted_at IS NULL;
+	`
+
+	err := r.db.QueryRowContext(ctx, query, ID).Scan(&lyrics.TotalVerses)
+	if errors.Is(err, sql.ErrNoRows) {
+		return lyrics, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+	if err != nil {
+		return lyrics, fmt.Errorf("failed to fetch song lyrics: %w", err)
+	}
+
+	if couplet < 1 || size < 1 || couplet > lyrics.TotalVerses {
+		return lyrics, nil
+	}
+
+	query = `
+		SELECT text
+		FROM verses
+		WHERE song_id = $1
+		ORDER BY position
+		OFFSET $2
+		LIMIT $3;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ID, couplet-1, size)
+	if err != nil {
+		return lyrics, fmt.Errorf("failed to fetch song lyrics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var verse string
+
+		if err := rows.Scan(&verse); err != nil {
+			return lyrics, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		lyrics.Verses = append(lyrics.Verses, verse)
+	}
+
+	if err := rows.Err(); err != nil {
+		return lyrics, err
+	}
+
+	return lyrics, nil
+}
+
+func (r *musicPostgres) AddMusic(ctx context.Context, music models.MusicInfo) (models.MusicInfo, error) {
 	query := `
-		SELECT id, music_group, song, release_date, text, link 
-		FROM musics 
-		WHERE (COALESCE($1, '') = '' OR music_group ILIKE '%' || $1 || '%') 
-		  AND (COALESCE($2, '') = '' OR song ILIKE '%' || $2 || '%') 
-		  AND (COALESCE($3, '') = '' OR release_date::TEXT ILIKE '%' || $3 || '%') 
-		  AND (COALESCE($4, '') = '' OR text ILIKE '%' || $4 || '%') 
-		LIMIT $5 
-		OFFSET $6;
+		INSERT INTO songs (artist_id, song, release_date, link, enrichment_status, enriched_at) 
+		VALUES (
+			$1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'pending'),
+			CASE WHEN $5 = 'enriched' THEN now() END
+		)
+		ON CONFLICT (artist_id, lower(song)) WHERE deleted_at IS NULL DO NOTHING
+		RETURNING id, song, release_date, link, enrichment_status, version;
 	`
 
-	stmt, err := r.db.PrepareContext(ctx, query)
+	stored := models.MusicInfo{Text: joinVerses(music.Text)}
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		artist, err := upsertArtist(ctx, tx, music.Group)
+		if err != nil {
+			return err
+		}
+
+		stored.Group = artist.Name
+
+		err = tx.QueryRowContext(
+			ctx,
+			query,
+			artist.ID,
+			music.Song,
+			music.RelaseDate,
+			music.Link,
+			music.EnrichmentStatus,
+		).Scan(&stored.ID, &stored.Song, &stored.RelaseDate, &stored.Link, &stored.EnrichmentStatus, &stored.Version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return existingSong(ctx, tx, artist.ID, music.Song)
+		}
+		if err != nil {
+			return wrapPostgresError(err)
+		}
+
+		if err := replaceVerses(ctx, tx, stored.ID, music.Text); err != nil {
+			return err
+		}
+
+		return insertRevisions(ctx, tx, []models.Revision{
+			{SongID: stored.ID, Rev: stored.Version, Action: models.RevisionInsert, New: &stored},
+		})
+	})
+	if err != nil {
+		return models.MusicInfo{}, err
+	}
+
+	return stored, nil
+}
+
+func (r *musicPostgres) AddMusics(ctx context.Context, musics []models.MusicInfo) ([]int, error) {
+	IDs := make([]int, len(musics))
+	if len(musics) == 0 {
+		return IDs, nil
+	}
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// Songs already stored are skipped by ON CONFLICT, repeats within
+		// the batch are dropped here so that the rest can be matched back.
+		var fresh []int
+
+		seen := make(map[songKey]bool, len(musics))
+
+		for i, music := range musics {
+			key := newSongKey(music.Group, music.Song)
+			if !seen[key] {
+				seen[key] = true
+				fresh = append(fresh, i)
+			}
+		}
+
+		groups := make([]string, 0, len(fresh))
+		for _, i := range fresh {
+			groups = append(groups, musics[i].Group)
+		}
+
+		artistIDs, err := upsertArtists(ctx, tx, groups)
+		if err != nil {
+			return err
+		}
+
+		var (
+			columns struct {
+				artistIDs                            []int64
+				songs, releaseDates, links, statuses []string
+			}
+			// The new songs are matched back by artist and title, which
+			// are unique within fresh.
+			indexes = make(map[artistSong]int, len(fresh))
+		)
+
+		for _, i := range fresh {
+			music := musics[i]
+
+			if music.EnrichmentStatus == "" {
+				music.EnrichmentStatus = models.EnrichmentPending
+			}
+
+			columns.artistIDs = append(columns.artistIDs, int64(artistIDs[music.Group]))
+			columns.songs = append(columns.songs, music.Song)
+			columns.releaseDates = append(columns.releaseDates, music.RelaseDate.String())
+			columns.links = append(columns.links, music.Link)
+			columns.statuses = append(columns.statuses, music.EnrichmentStatus)
+
+			indexes[artistSong{artistIDs[music.Group], strings.ToLower(music.Song)}] = i
+		}
+
+		query := `
+			INSERT INTO songs (artist_id, song, release_date, link, enrichment_status, enriched_at)
+			SELECT n.artist_id, n.song, NULLIF(n.release_date, '')::DATE, n.link, n.status,
+			       CASE WHEN n.status = 'enriched' THEN now() END
+			FROM unnest($1::INT[], $2::TEXT[], $3::TEXT[], $4::TEXT[], $5::TEXT[])
+			     AS n (artist_id, song, release_date, link, status)
+			ON CONFLICT (artist_id, lower(song)) WHERE deleted_at IS NULL DO NOTHING
+			RETURNING id, artist_id, song;
+		`
+
+		rows, err := tx.QueryContext(
+			ctx,
+			query,
+			pq.Array(columns.artistIDs),
+			pq.Array(columns.songs),
+			pq.Array(columns.releaseDates),
+			pq.Array(columns.links),
+			pq.Array(columns.statuses),
+		)
+		if err != nil {
+			return wrapPostgresError(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				ID  int
+				key artistSong
+			)
+
+			if err := rows.Scan(&ID, &key.artistID, &key.song); err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+
+			key.song = strings.ToLower(key.song)
+
+			IDs[indexes[key]] = ID
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if err := insertVerses(ctx, tx, musics, IDs); err != nil {
+			return err
+		}
+
+		return insertBatchRevisions(ctx, tx, IDs)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := stmt.QueryContext(ctx, group, song, releaseDate, text, limit, offset)
+	return IDs, nil
+}
+
+type artistSong struct {
+	artistID int
+	song     string
+}
+
+func (r *musicPostgres) UpdateMusic(
+	ctx context.Context, ID int, updates models.MusicUpdate, version int,
+) (models.MusicInfo, error) {
+	if err := checkUpdate(updates); err != nil {
+		return models.MusicInfo{}, err
+	}
+
+	var music models.MusicInfo
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		old, err := lockMusic(ctx, tx, ID, version)
+		if err != nil {
+			return err
+		}
+
+		// Bumped even when only the lyrics change.
+		builder := psql.Update("songs").Set("version", sq.Expr("version + 1")).Where(sq.Eq{"id": ID})
+
+		if updates.Group.Replaces() {
+			artist, err := upsertArtist(ctx, tx, updates.Group.Value)
+			if err != nil {
+				return err
+			}
+
+			builder = builder.Set("artist_id", artist.ID)
+		}
+
+		if updates.Song.Replaces() {
+			builder = builder.Set("song", updates.Song.Value)
+		}
+
+		if updates.RelaseDate.Set {
+			builder = builder.Set("release_date", updates.RelaseDate.Value)
+		}
+
+		// Songs without a link have an empty one.
+		if updates.Link.Set {
+			builder = builder.Set("link", updates.Link.Value)
+		}
+
+		query, args, err := builder.ToSql()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("Failed to update music: %w", wrapPostgresError(err))
+		}
+
+		if updates.Text.Set {
+			if err := replaceVerses(ctx, tx, ID, updates.Text.Value); err != nil {
+				return err
+			}
+		}
+
+		music, err = getMusic(ctx, tx, ID)
+		if err != nil {
+			return err
+		}
+
+		return insertRevisions(ctx, tx, []models.Revision{
+			{SongID: ID, Rev: music.Version, Action: models.RevisionUpdate, Old: &old, New: &music},
+		})
+	})
+	if err != nil {
+		return models.MusicInfo{}, err
+	}
+
+	return music, nil
+}
+
+func (r *musicPostgres) DeleteMusic(ctx context.Context, ID, version int) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		old, err := lockMusic(ctx, tx, ID, version)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE songs SET deleted_at = now(), version = version + 1 WHERE id = $1;", ID); err != nil {
+			return fmt.Errorf("failed to delete song: %w", err)
+		}
+
+		return insertRevisions(ctx, tx, []models.Revision{
+			{SongID: ID, Rev: old.Version + 1, Action: models.RevisionDelete, Old: &old},
+		})
+	})
+}
+
+func (r *musicPostgres) GetDeletedMusics(ctx context.Context, limit, offset int) ([]models.DeletedMusic, error) {
+	query := `
+		SELECT ` + musicColumns + `, s.deleted_at
+		FROM ` + musicSource + `
+		WHERE s.deleted_at IS NOT NULL
+		ORDER BY s.deleted_at DESC, s.id DESC
+		LIMIT $1
+		OFFSET $2;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
-	var result []models.MusicInfo
+	var result []models.DeletedMusic
 
 	for rows.Next() {
-		var music models.MusicInfo
+		var music models.DeletedMusic
 
-		if err := rows.Scan(
-			&music.ID,
-			&music.Group,
-			&music.Song,
-			&music.RelaseDate,
-			&music.Text,
-			&music.Link,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+		music.MusicInfo, err = scanMusic(rows, &music.DeletedAt)
+		if err != nil {
+			return nil, err
 		}
 
 		result = append(result, music)
@@ -77,112 +447,266 @@ func (r *musicPostgres) GetMusics(
 	return result, nil
 }
 
-func (r *musicPostgres) GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) ([]string, error) {
-	query := "SELECT text FROM musics WHERE id = $1;"
+func (r *musicPostgres) RestoreMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	query := `
+		UPDATE songs
+		SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL;
+	`
 
-	var text string
+	var music models.MusicInfo
 
-	err := r.db.QueryRowContext(ctx, query, ID).Scan(&text)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("song not found")
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, query, ID)
+		if err != nil {
+			return fmt.Errorf("failed to restore song: %w", wrapPostgresError(err))
+		}
+
+		if err := checkAffected(res, ID); err != nil {
+			return err
+		}
+
+		music, err = getMusic(ctx, tx, ID)
+		if err != nil {
+			return err
 		}
 
-		return nil, fmt.Errorf("failed to fetch song lyrics: %w", err)
+		return insertRevisions(ctx, tx, []models.Revision{
+			{SongID: ID, Rev: music.Version, Action: models.RevisionRestore, New: &music},
+		})
+	})
+	if err != nil {
+		return models.MusicInfo{}, err
 	}
 
-	verses := strings.Split(text, "\\n\\n")
+	return music, nil
+}
 
-	start := (couplet - 1)
+func (r *musicPostgres) PurgeMusics(ctx context.Context, before time.Time) (int, error) {
+	query := `
+		WITH purged AS (
+			DELETE FROM songs WHERE deleted_at < $1 RETURNING id, version
+		)
+		INSERT INTO song_revisions (song_id, rev, action, actor, request_id)
+		SELECT id, version + 1, $2, NULLIF($3, ''), NULLIF($4, '')
+		FROM purged;
+	`
+
+	audit := models.AuditFrom(ctx)
 
-	end := start + size
-	if end > len(verses) {
-		end = len(verses)
+	res, err := r.db.ExecContext(ctx, query, before, models.RevisionPurge, audit.Actor, audit.RequestID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge songs: %w", err)
 	}
 
-	return verses[start:end], nil
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(purged), nil
 }
 
-func (r *musicPostgres) AddMusic(ctx context.Context, music models.MusicInfo) error {
+func (r *musicPostgres) GetMusicHistory(ctx context.Context, ID, limit, offset int) ([]models.Revision, error) {
+	var exists bool
+
 	query := `
-		INSERT INTO musics (music_group, song, release_date, text, link) 
-		VALUES ($1, $2, $3, $4, $5);
+		SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1)
+		    OR EXISTS (SELECT 1 FROM song_revisions WHERE song_id = $1);
 	`
 
-	stmt, err := r.db.PrepareContext(ctx, query)
+	if err := r.db.QueryRowContext(ctx, query, ID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to fetch song: %w", err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, selectRevisions+"WHERE song_id = $1 ORDER BY rev DESC LIMIT $2 OFFSET $3;", ID, limit, offset)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
-	defer stmt.Close()
-
-	if _, err := stmt.ExecContext(
-		ctx,
-		query,
-		music.Group,
-		music.Song,
-		music.RelaseDate,
-		music.Text,
-		music.Link,
-	); err != nil {
-		return err
+	defer rows.Close()
+
+	var result []models.Revision
+
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, revision)
 	}
 
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-func (r *musicPostgres) UpdateMusic(ctx context.Context, ID int, updates models.MusicUpdate) error {
-	if reflect.ValueOf(updates).IsZero() {
-		return fmt.Errorf("No updates provided")
-	}
+func (r *musicPostgres) RevertMusic(ctx context.Context, ID, rev, version int) (models.MusicInfo, error) {
+	query := `
+		UPDATE songs
+		SET artist_id = $2, song = $3, release_date = $4, link = $5, version = version + 1
+		WHERE id = $1;
+	`
+
+	var music models.MusicInfo
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		old, err := lockMusic(ctx, tx, ID, version)
+		if err != nil {
+			return err
+		}
+
+		revision, err := getRevision(ctx, tx, ID, rev)
+		if err != nil {
+			return err
+		}
+
+		if revision.New == nil {
+			return fmt.Errorf("%w: revision %d of song %d deleted it", models.ErrValidation, rev, ID)
+		}
 
-	query := "UPDATE musics SET "
-	var args []any
-	argCounter := 1
+		target := revision.New
 
-	val := reflect.ValueOf(updates)
-	typ := reflect.TypeOf(updates)
+		artist, err := upsertArtist(ctx, tx, target.Group)
+		if err != nil {
+			return err
+		}
 
-	for i := 0; i < val.NumField(); i++ {
-		field := typ.Field(i)
-		value := val.Field(i)
+		if _, err := tx.ExecContext(ctx, query, ID, artist.ID, target.Song, target.RelaseDate, target.Link); err != nil {
+			return fmt.Errorf("failed to revert song: %w", wrapPostgresError(err))
+		}
 
-		if value.IsZero() {
-			continue
+		if err := replaceVerses(ctx, tx, ID, target.Text); err != nil {
+			return err
 		}
 
-		columnName := field.Tag.Get("db")
-		if columnName == "" {
-			columnName = field.Tag.Get("json")
+		music, err = getMusic(ctx, tx, ID)
+		if err != nil {
+			return err
 		}
 
-		query += fmt.Sprintf("%s = $%d, ", columnName, argCounter)
-		args = append(args, value.Interface())
-		argCounter++
+		return insertRevisions(ctx, tx, []models.Revision{
+			{SongID: ID, Rev: music.Version, Action: models.RevisionRevert, Old: &old, New: &music},
+		})
+	})
+	if err != nil {
+		return models.MusicInfo{}, err
 	}
 
-	query = strings.TrimSuffix(query, ", ")
-	query += fmt.Sprintf(" WHERE id = $%d", argCounter)
-	args = append(args, ID)
+	return music, nil
+}
 
-	stmt, err := r.db.PrepareContext(ctx, query)
+// exportFetchSize is the number of songs fetched at once from the cursor of
+// ExportMusics.
+const exportFetchSize = 500
+
+// ExportMusics reads the songs through a server-side cursor, so that neither
+// the database nor the service hold the whole catalog at once.
+func (r *musicPostgres) ExportMusics(
+	ctx context.Context, filter models.MusicFilter, yield func(models.MusicInfo) error,
+) error {
+	builder, err := filterMusics(filter, filter.SortKeys())
 	if err != nil {
 		return err
 	}
 
-	if _, err := stmt.ExecContext(ctx, args...); err != nil {
-		return fmt.Errorf("Failed to update music: %w", err)
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DECLARE export_musics NO SCROLL CURSOR FOR "+query, args...); err != nil {
+			return fmt.Errorf("failed to declare cursor: %w", err)
+		}
+
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_musics;", exportFetchSize)
+
+		for {
+			rows, err := tx.QueryContext(ctx, fetch)
+			if err != nil {
+				return fmt.Errorf("failed to fetch songs: %w", err)
+			}
 
+			musics, err := scanMusics(rows)
+			if err != nil {
+				return err
+			}
+
+			for _, music := range musics {
+				if err := yield(music); err != nil {
+					return err
+				}
+			}
+
+			if len(musics) < exportFetchSize {
+				return nil
+			}
+		}
+	})
 }
 
-func (r *musicPostgres) DeleteMusic(ctx context.Context, ID int) error {
-	query := "DELETE FROM music WHERE id = $1;"
+// getMusic reads the song with the given ID as selected by selectMusics.
+func getMusic(ctx context.Context, db queryRower, ID int) (models.MusicInfo, error) {
+	music, err := scanMusic(db.QueryRowContext(ctx, selectMusics+"WHERE s.id = $1 AND s.deleted_at IS NULL;", ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return music, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
 
-	if _, err := r.db.ExecContext(ctx, query, ID); err != nil {
-		return err
+	return music, err
+}
+
+// scanMusics reads and closes rows selected with selectMusics.
+func scanMusics(rows *sql.Rows) ([]models.MusicInfo, error) {
+	defer rows.Close()
+
+	var result []models.MusicInfo
+
+	for rows.Next() {
+		music, err := scanMusic(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, music)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanMusic reads a row of musicColumns followed by the columns of extra.
+func scanMusic(row rowScanner, extra ...any) (models.MusicInfo, error) {
+	var music models.MusicInfo
+
+	dest := []any{
+		&music.ID,
+		&music.Group,
+		&music.Song,
+		&music.RelaseDate,
+		&music.Text,
+		&music.Link,
+		&music.EnrichmentStatus,
+		&music.Version,
+	}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return music, fmt.Errorf("failed to scan row: %w", err)
 	}
 
-	return nil
+	return music, err
 }
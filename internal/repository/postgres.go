@@ -1,20 +1,45 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"log"
 	"music/internal/config"
+	"music/internal/models"
 
-	_ "github.com/lib/pq"
+	"github.com/XSAM/otelsql"
+	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
 )
 
 type Postgres struct {
 	db *sql.DB
 }
 
+// NewPostgresDB opens the database with a span per SQL statement. Only the
+// statements made within a trace are traced, not those of the background
+// jobs outside of one.
 func NewPostgresDB(cfg config.Config) DB {
-	db, err := sql.Open("postgres", GetDBUrl(cfg))
+	db, err := otelsql.Open("postgres", GetDBUrl(cfg),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBNamespace(cfg.DBName)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			DisableErrSkip:       true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -36,3 +61,31 @@ func GetDBUrl(cfg config.Config) string {
 		cfg.DBUsername, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.DBSSLMode,
 	)
 }
+
+// wrapPostgresError translates driver errors into domain errors.
+func wrapPostgresError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pgUniqueViolation, pgForeignKeyViolation:
+			return fmt.Errorf("%w: %s", models.ErrConflict, pqErr.Detail)
+		}
+	}
+
+	return err
+}
+
+// checkAffected reports models.ErrNotFound when a statement addressed to a
+// single song changed nothing.
+func checkAffected(res sql.Result, ID int) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"music/internal/models"
+	"sync"
+	"time"
+)
+
+// rateMemory keeps the buckets of a single replica.
+type rateMemory struct {
+	mu      sync.Mutex
+	buckets map[string]models.RateBucket
+	swept   time.Time
+}
+
+// NewRateLimitMemory returns buckets kept in memory, for a single replica
+// even when the rest of the data is in Postgres.
+func NewRateLimitMemory() RateLimit {
+	return &rateMemory{buckets: make(map[string]models.RateBucket)}
+}
+
+func (r *rateMemory) TakeRateLimitToken(
+	ctx context.Context, key string, limit models.RateLimit, now time.Time,
+) (models.RateLimitResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.swept) >= limit.Per {
+		for k, bucket := range r.buckets {
+			if now.Sub(bucket.UpdatedAt) > limit.Per {
+				delete(r.buckets, k)
+			}
+		}
+
+		r.swept = now
+	}
+
+	bucket, result := limit.Take(r.buckets[key], now)
+	r.buckets[key] = bucket
+
+	return result, nil
+}
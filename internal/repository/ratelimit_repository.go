@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"music/internal/models"
+	"sync"
+	"time"
+)
+
+type RateLimit interface {
+	// TakeRateLimitToken takes a token from the bucket of key at now. Buckets
+	// idle for longer than limit.Per are full and may be forgotten.
+	TakeRateLimitToken(
+		ctx context.Context, key string, limit models.RateLimit, now time.Time,
+	) (models.RateLimitResult, error)
+}
+
+// ratePostgres shares the buckets between the replicas of the service.
+type ratePostgres struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	swept time.Time
+}
+
+func newRatePostgres(db *sql.DB) RateLimit {
+	return &ratePostgres{db: db}
+}
+
+func (r *ratePostgres) TakeRateLimitToken(
+	ctx context.Context, key string, limit models.RateLimit, now time.Time,
+) (models.RateLimitResult, error) {
+	if err := r.sweep(ctx, limit, now); err != nil {
+		return models.RateLimitResult{}, err
+	}
+
+	// The upsert locks the bucket of a known key as well as a new one.
+	lock := `
+		INSERT INTO rate_limits (key, tokens, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET key = EXCLUDED.key
+		RETURNING tokens, updated_at;
+	`
+
+	var result models.RateLimitResult
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var bucket models.RateBucket
+
+		err := tx.QueryRowContext(ctx, lock, key, limit.Requests, now).Scan(&bucket.Tokens, &bucket.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to lock rate limit bucket: %w", err)
+		}
+
+		bucket, result = limit.Take(bucket, now)
+
+		_, err = tx.ExecContext(
+			ctx, "UPDATE rate_limits SET tokens = $2, updated_at = $3 WHERE key = $1;", key, bucket.Tokens, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update rate limit bucket: %w", err)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// sweep deletes the full buckets at most once per limit.Per.
+func (r *ratePostgres) sweep(ctx context.Context, limit models.RateLimit, now time.Time) error {
+	r.mu.Lock()
+	due := now.Sub(r.swept) >= limit.Per
+	if due {
+		r.swept = now
+	}
+	r.mu.Unlock()
+
+	if !due {
+		return nil
+	}
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE updated_at < $1;", now.Add(-limit.Per)); err != nil {
+		return fmt.Errorf("failed to purge rate limit buckets: %w", err)
+	}
+
+	return nil
+}
@@ -11,10 +11,36 @@ type DB interface {
 
 type Repository struct {
 	Music
+	Enrichment
+	Artist
+	Search
+	Idempotency
+	APIKey
+	RateLimit
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	return &Repository{
-		Music: newMusicPostgres(db),
+		Music:       newMusicPostgres(db),
+		Enrichment:  newEnrichmentPostgres(db),
+		Artist:      newArtistPostgres(db),
+		Search:      newSearchPostgres(db),
+		Idempotency: newIdempotencyPostgres(db),
+		APIKey:      newAPIKeyPostgres(db),
+		RateLimit:   newRatePostgres(db),
+	}
+}
+
+func NewMemoryRepository() *Repository {
+	musics := newMusicMemory()
+
+	return &Repository{
+		Music:       musics,
+		Enrichment:  musics,
+		Artist:      musics,
+		Search:      musics,
+		Idempotency: newIdempotencyMemory(),
+		APIKey:      newAPIKeyMemory(),
+		RateLimit:   NewRateLimitMemory(),
 	}
 }
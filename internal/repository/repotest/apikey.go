@@ -0,0 +1,57 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+)
+
+func testAPIKeys(t *testing.T, repo repository.APIKey) {
+	ctx := context.Background()
+
+	key, err := repo.AddAPIKey(ctx, models.APIKey{Name: "ci", Role: models.RoleEditor}, "hash-1")
+	if err != nil || key.ID == 0 || key.Name != "ci" || key.Role != models.RoleEditor || key.CreatedAt.IsZero() {
+		t.Fatalf("AddAPIKey = %+v, %v", key, err)
+	}
+
+	if _, err := repo.AddAPIKey(ctx, models.APIKey{Name: "ci", Role: models.RoleAdmin}, "hash-2"); !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("AddAPIKey with a name in use = %v, want ErrConflict", err)
+	}
+
+	got, err := repo.GetAPIKeyByHash(ctx, "hash-1")
+	if err != nil || got.ID != key.ID || got.Role != models.RoleEditor || got.RevokedAt != nil {
+		t.Fatalf("GetAPIKeyByHash = %+v, %v", got, err)
+	}
+
+	if _, err := repo.GetAPIKeyByHash(ctx, "unknown"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("GetAPIKeyByHash of an unknown hash = %v, want ErrNotFound", err)
+	}
+
+	if err := repo.RevokeAPIKey(ctx, key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+
+	if err := repo.RevokeAPIKey(ctx, key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey of a revoked key: %v", err)
+	}
+
+	if err := repo.RevokeAPIKey(ctx, key.ID+100); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("RevokeAPIKey of a missing key = %v, want ErrNotFound", err)
+	}
+
+	if _, err := repo.GetAPIKeyByHash(ctx, "hash-1"); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("GetAPIKeyByHash of a revoked key = %v, want ErrNotFound", err)
+	}
+
+	// The name of a revoked key can be reused.
+	if _, err := repo.AddAPIKey(ctx, models.APIKey{Name: "ci", Role: models.RoleReadOnly}, "hash-3"); err != nil {
+		t.Fatalf("AddAPIKey with the name of a revoked key: %v", err)
+	}
+
+	keys, err := repo.GetAPIKeys(ctx)
+	if err != nil || len(keys) != 2 || keys[0].RevokedAt == nil || keys[1].RevokedAt != nil {
+		t.Fatalf("GetAPIKeys = %+v, %v", keys, err)
+	}
+}
@@ -0,0 +1,81 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+)
+
+func testArtists(t *testing.T, repos *repository.Repository) {
+	ctx := context.Background()
+	musics := seed(t, repos.Music)
+
+	artists, err := repos.GetArtists(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetArtists: %v", err)
+	}
+	if len(artists) != 2 || artists[0].Name != "Muse" || artists[1].Name != "Radiohead" {
+		t.Fatalf("GetArtists got %+v, want Muse and Radiohead", artists)
+	}
+	muse, radiohead := artists[0], artists[1]
+
+	if _, err := repos.AddArtist(ctx, "Muse"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("AddArtist of an existing name: got %v, want %v", err, models.ErrConflict)
+	}
+
+	if err := repos.DeleteArtist(ctx, muse.ID); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("DeleteArtist with songs: got %v, want %v", err, models.ErrConflict)
+	}
+
+	// Renaming renames the songs too.
+	renamed, err := repos.RenameArtist(ctx, radiohead.ID, "On a Friday")
+	if err != nil {
+		t.Fatalf("RenameArtist: %v", err)
+	}
+	if renamed.ID != radiohead.ID || find(t, repos.Music, musics[2].ID).Group != "On a Friday" {
+		t.Errorf("RenameArtist got %+v and song %+v", renamed, find(t, repos.Music, musics[2].ID))
+	}
+
+	// Renaming to an existing name merges the songs into that artist.
+	merged, err := repos.RenameArtist(ctx, radiohead.ID, "Muse")
+	if err != nil {
+		t.Fatalf("RenameArtist: %v", err)
+	}
+	if merged.ID != muse.ID {
+		t.Errorf("RenameArtist to an existing name got %+v, want %+v", merged, muse)
+	}
+
+	songs, err := repos.GetArtistSongs(ctx, muse.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetArtistSongs: %v", err)
+	}
+	assertSongs(t, songs, []string{"Supermassive Black Hole", "Uprising", "Creep"})
+
+	// Both the rename and the merge are in the history of the songs.
+	history := assertHistory(t, repos.Music, musics[2].ID, models.RevisionUpdate, models.RevisionUpdate, models.RevisionInsert)
+	if history[0].Rev != songs[2].Version || history[0].Old.Group != "On a Friday" || history[0].New.Group != "Muse" {
+		t.Errorf("merge revision = %+v, want On a Friday to Muse at version %d", history[0], songs[2].Version)
+	}
+	if history[1].Old.Group != "Radiohead" || history[1].New.Group != "On a Friday" {
+		t.Errorf("rename revision = %+v, want Radiohead to On a Friday", history[1])
+	}
+
+	if _, err := repos.GetArtist(ctx, radiohead.ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetArtist of a merged artist: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	empty, err := repos.AddArtist(ctx, "Queen")
+	if err != nil {
+		t.Fatalf("AddArtist: %v", err)
+	}
+
+	if err := repos.DeleteArtist(ctx, empty.ID); err != nil {
+		t.Errorf("DeleteArtist: %v", err)
+	}
+
+	if _, err := repos.GetArtistSongs(ctx, empty.ID, 10, 0); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetArtistSongs of a deleted artist: got %v, want %v", err, models.ErrNotFound)
+	}
+}
@@ -0,0 +1,176 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+	"time"
+)
+
+func testEnrichment(t *testing.T, repos *repository.Repository) {
+	ctx := context.Background()
+	seed(t, repos.Music)
+
+	added, err := repos.AddMusic(ctx, models.MusicInfo{Group: "Queen", Song: "Bohemian Rhapsody"})
+	if err != nil {
+		t.Fatalf("AddMusic: %v", err)
+	}
+	first := added.ID
+
+	added, err = repos.AddMusic(ctx, models.MusicInfo{Group: "Queen", Song: "Innuendo"})
+	if err != nil {
+		t.Fatalf("AddMusic: %v", err)
+	}
+	second := added.ID
+
+	assertPending(t, repos, first, second)
+
+	// A failed attempt is retried later, the last one fails the song for good.
+	// Only that changes the song.
+	before := find(t, repos.Music, first)
+
+	if err := repos.FailEnrichment(ctx, first, "timeout", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("FailEnrichment: %v", err)
+	}
+	assertPending(t, repos, second)
+
+	status, err := repos.GetEnrichmentStatus(ctx, first)
+	if err != nil {
+		t.Fatalf("GetEnrichmentStatus: %v", err)
+	}
+	if status.Status != models.EnrichmentPending || status.Attempts != 1 || status.Error != "timeout" {
+		t.Errorf("after a retryable failure got %+v", status)
+	}
+	if got := find(t, repos.Music, first); got != before {
+		t.Errorf("after a retryable failure the song is %+v, want %+v", got, before)
+	}
+
+	if err := repos.FailEnrichment(ctx, first, "gone", time.Time{}); err != nil {
+		t.Fatalf("FailEnrichment: %v", err)
+	}
+
+	status, err = repos.GetEnrichmentStatus(ctx, first)
+	if err != nil {
+		t.Fatalf("GetEnrichmentStatus: %v", err)
+	}
+	if status.Status != models.EnrichmentFailed || status.Attempts != 2 || status.NextAt != nil {
+		t.Errorf("after a final failure got %+v", status)
+	}
+
+	failed := find(t, repos.Music, first)
+	if failed.Version != before.Version+1 || failed.EnrichmentStatus != models.EnrichmentFailed {
+		t.Errorf("after a final failure the song is %+v, want version %d and failed", failed, before.Version+1)
+	}
+
+	history := assertHistory(t, repos.Music, first, models.RevisionEnrich, models.RevisionInsert)
+	if history[0].Rev != failed.Version || history[0].New == nil || *history[0].New != failed {
+		t.Errorf("failure revision = %+v, want %+v at rev %d", history[0], failed, failed.Version)
+	}
+
+	details := models.MusicInfo{RelaseDate: models.NewDate(1991, time.February, 4), Text: "While the sun hangs in the sky", Link: "https://example.com"}
+	if err := repos.CompleteEnrichment(ctx, second, details); err != nil {
+		t.Fatalf("CompleteEnrichment: %v", err)
+	}
+	assertPending(t, repos)
+
+	status, err = repos.GetEnrichmentStatus(ctx, second)
+	if err != nil {
+		t.Fatalf("GetEnrichmentStatus: %v", err)
+	}
+	if status.Status != models.EnrichmentEnriched || status.EnrichedAt == nil {
+		t.Errorf("after enrichment got %+v", status)
+	}
+
+	got := find(t, repos.Music, second)
+	if got.RelaseDate != details.RelaseDate || got.Text != details.Text || got.Link != details.Link {
+		t.Errorf("after enrichment got %+v, want the details of %+v", got, details)
+	}
+
+	// The enrichment is recorded in the history.
+	history = assertHistory(t, repos.Music, second, models.RevisionEnrich, models.RevisionInsert)
+	if history[0].New == nil || *history[0].New != got {
+		t.Errorf("enrich revision = %+v, want %+v", history[0], got)
+	}
+
+	// What users set while the song was pending is kept, the rest is filled.
+	added, err = repos.AddMusic(ctx, models.MusicInfo{Group: "Queen", Song: "The Show Must Go On"})
+	if err != nil {
+		t.Fatalf("AddMusic: %v", err)
+	}
+
+	edited, err := repos.UpdateMusic(ctx, added.ID, models.MusicUpdate{
+		Link: models.Set("https://queen.example.com"),
+	}, 0)
+	if err != nil {
+		t.Fatalf("UpdateMusic: %v", err)
+	}
+
+	if err := repos.CompleteEnrichment(ctx, added.ID, details); err != nil {
+		t.Fatalf("CompleteEnrichment: %v", err)
+	}
+
+	got = find(t, repos.Music, added.ID)
+	if got.Link != edited.Link || got.Text != details.Text || got.RelaseDate != details.RelaseDate {
+		t.Errorf("enrichment after an edit got %+v, want the link %q kept and the rest filled", got, edited.Link)
+	}
+
+	if _, err := repos.GetEnrichmentStatus(ctx, second+100); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetEnrichmentStatus of a missing song: got %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+func testClaimEnrichment(t *testing.T, repos *repository.Repository) {
+	ctx := context.Background()
+
+	var IDs []int
+
+	for _, song := range []string{"Bohemian Rhapsody", "Innuendo", "The Show Must Go On"} {
+		added, err := repos.AddMusic(ctx, models.MusicInfo{Group: "Queen", Song: song})
+		if err != nil {
+			t.Fatalf("AddMusic: %v", err)
+		}
+
+		IDs = append(IDs, added.ID)
+	}
+
+	tasks, err := repos.ClaimPendingEnrichments(ctx, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("ClaimPendingEnrichments: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != IDs[0] || tasks[1].ID != IDs[1] {
+		t.Fatalf("got claimed %+v, want IDs %v", tasks, IDs[:2])
+	}
+
+	// Claimed songs are left to their claimer until the lease has passed.
+	assertPending(t, repos, IDs[2])
+
+	status, err := repos.GetEnrichmentStatus(ctx, IDs[0])
+	if err != nil {
+		t.Fatalf("GetEnrichmentStatus: %v", err)
+	}
+	if status.Status != models.EnrichmentPending || status.NextAt == nil || time.Until(*status.NextAt) < 59*time.Minute {
+		t.Errorf("claimed song has status %+v, want it pending for another hour", status)
+	}
+}
+
+func assertPending(t *testing.T, repos *repository.Repository, want ...int) {
+	t.Helper()
+
+	// Without a lease the songs stay due.
+	tasks, err := repos.ClaimPendingEnrichments(context.Background(), 10, 0)
+	if err != nil {
+		t.Fatalf("ClaimPendingEnrichments: %v", err)
+	}
+
+	if len(tasks) != len(want) {
+		t.Fatalf("got pending %+v, want IDs %v", tasks, want)
+	}
+
+	for i, task := range tasks {
+		if task.ID != want[i] {
+			t.Fatalf("got pending %+v, want IDs %v", tasks, want)
+		}
+	}
+}
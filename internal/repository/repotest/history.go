@@ -0,0 +1,143 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+)
+
+func testHistory(t *testing.T, repo repository.Music) {
+	audit := models.Audit{Actor: "editor", RequestID: "request-1"}
+	ctx := models.WithAudit(context.Background(), audit)
+	musics := seed(t, repo)
+
+	updated, err := repo.UpdateMusic(ctx, musics[0].ID, models.MusicUpdate{Text: models.Set("Rewritten")}, 0)
+	if err != nil {
+		t.Fatalf("UpdateMusic: %v", err)
+	}
+
+	history := assertHistory(t, repo, musics[0].ID, models.RevisionUpdate, models.RevisionInsert)
+
+	if history[0].Rev != updated.Version || history[1].Rev != musics[0].Version {
+		t.Errorf("revisions %d and %d, want versions %d and %d",
+			history[0].Rev, history[1].Rev, updated.Version, musics[0].Version)
+	}
+	if history[0].Old == nil || *history[0].Old != musics[0] || history[0].New == nil || *history[0].New != updated {
+		t.Errorf("update revision = %+v, want %+v to %+v", history[0], musics[0], updated)
+	}
+	if history[0].Actor != audit.Actor || history[0].RequestID != audit.RequestID || history[0].CreatedAt.IsZero() {
+		t.Errorf("update revision = %+v, want the audit %+v", history[0], audit)
+	}
+	if history[1].Old != nil || history[1].New == nil || *history[1].New != musics[0] {
+		t.Errorf("insert revision = %+v, want nothing to %+v", history[1], musics[0])
+	}
+
+	page, err := repo.GetMusicHistory(ctx, musics[0].ID, 1, 1)
+	if err != nil {
+		t.Fatalf("GetMusicHistory: %v", err)
+	}
+	if len(page) != 1 || page[0].Action != models.RevisionInsert {
+		t.Errorf("GetMusicHistory(limit 1, offset 1) = %+v, want the insert", page)
+	}
+
+	if _, err := repo.GetMusicHistory(ctx, 999, 10, 0); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetMusicHistory of a missing song: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	// Reverting brings the stored song back as a new revision.
+	reverted, err := repo.RevertMusic(ctx, musics[0].ID, musics[0].Version, updated.Version)
+	if err != nil {
+		t.Fatalf("RevertMusic: %v", err)
+	}
+	if reverted.Text != musics[0].Text || reverted.Version <= updated.Version {
+		t.Errorf("RevertMusic = %+v, want the text %q at a newer version", reverted, musics[0].Text)
+	}
+	if got := find(t, repo, musics[0].ID); got != reverted {
+		t.Errorf("after RevertMusic got %+v, want %+v", got, reverted)
+	}
+
+	assertHistory(t, repo, musics[0].ID, models.RevisionRevert, models.RevisionUpdate, models.RevisionInsert)
+
+	_, err = repo.RevertMusic(ctx, musics[0].ID, musics[0].Version, updated.Version)
+	if !errors.Is(err, models.ErrPreconditionFailed) {
+		t.Errorf("RevertMusic at a stale version: got %v, want %v", err, models.ErrPreconditionFailed)
+	}
+
+	if _, err := repo.RevertMusic(ctx, musics[0].ID, 999, 0); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("RevertMusic to a missing revision: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	// A revision that takes the title of another song conflicts.
+	renamed, err := repo.UpdateMusic(ctx, musics[1].ID, models.MusicUpdate{Song: models.Set("Renamed")}, 0)
+	if err != nil {
+		t.Fatalf("UpdateMusic: %v", err)
+	}
+
+	if _, err := repo.AddMusic(ctx, models.MusicInfo{Group: musics[1].Group, Song: musics[1].Song}); err != nil {
+		t.Fatalf("AddMusic: %v", err)
+	}
+
+	if _, err := repo.RevertMusic(ctx, musics[1].ID, musics[1].Version, 0); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("RevertMusic over another song: got %v, want %v", err, models.ErrConflict)
+	}
+	if got := find(t, repo, musics[1].ID); got != renamed {
+		t.Errorf("after a failed RevertMusic got %+v, want %+v", got, renamed)
+	}
+
+	// Deleting and restoring are recorded, the deletion cannot be reverted to.
+	if err := repo.DeleteMusic(ctx, musics[2].ID, 0); err != nil {
+		t.Fatalf("DeleteMusic: %v", err)
+	}
+
+	if _, err := repo.RevertMusic(ctx, musics[2].ID, musics[2].Version, 0); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("RevertMusic of a deleted song: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	if _, err := repo.RestoreMusic(ctx, musics[2].ID); err != nil {
+		t.Fatalf("RestoreMusic: %v", err)
+	}
+
+	history = assertHistory(t, repo, musics[2].ID, models.RevisionRestore, models.RevisionDelete, models.RevisionInsert)
+
+	if history[1].Old == nil || *history[1].Old != musics[2] || history[1].New != nil {
+		t.Errorf("delete revision = %+v, want %+v to nothing", history[1], musics[2])
+	}
+
+	if _, err := repo.RevertMusic(ctx, musics[2].ID, history[1].Rev, 0); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("RevertMusic to a deletion: got %v, want %v", err, models.ErrValidation)
+	}
+
+	// Songs of a batch are recorded too.
+	IDs, err := repo.AddMusics(ctx, []models.MusicInfo{{Group: "Queen", Song: "Bohemian Rhapsody", Text: "Is this the real life?"}})
+	if err != nil {
+		t.Fatalf("AddMusics: %v", err)
+	}
+
+	history = assertHistory(t, repo, IDs[0], models.RevisionInsert)
+
+	if history[0].New == nil || *history[0].New != find(t, repo, IDs[0]) || history[0].Actor != audit.Actor {
+		t.Errorf("insert revision of a batch = %+v, want the stored song", history[0])
+	}
+}
+
+// assertHistory checks the actions of the history of a song, the latest
+// first, and returns it.
+func assertHistory(t *testing.T, repo repository.Music, ID int, actions ...string) []models.Revision {
+	t.Helper()
+
+	history, err := repo.GetMusicHistory(context.Background(), ID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetMusicHistory: %v", err)
+	}
+
+	got := make([]string, 0, len(history))
+	for _, revision := range history {
+		got = append(got, revision.Action)
+	}
+
+	assertStrings(t, got, actions)
+
+	return history
+}
@@ -0,0 +1,91 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"music/internal/models"
+	"music/internal/repository"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testIdempotency(t *testing.T, repo repository.Idempotency) {
+	ctx := context.Background()
+
+	if _, reserved, err := repo.ReserveIdempotencyKey(ctx, "key", "first", time.Hour); err != nil || !reserved {
+		t.Fatalf("ReserveIdempotencyKey of a new key = %v, %v", reserved, err)
+	}
+
+	record, reserved, err := repo.ReserveIdempotencyKey(ctx, "key", "second", time.Hour)
+	if err != nil || reserved || record.Fingerprint != "first" || record.Completed {
+		t.Fatalf("ReserveIdempotencyKey of a key in progress = %+v, %v, %v", record, reserved, err)
+	}
+
+	err = repo.CompleteIdempotencyKey(ctx, "key", models.IdempotencyRecord{
+		Fingerprint: "final",
+		Status:      201,
+		Header:      map[string]string{"Location": "/1"},
+		Body:        []byte(`{"id":1}`),
+	})
+	if err != nil {
+		t.Fatalf("CompleteIdempotencyKey: %v", err)
+	}
+
+	record, reserved, err = repo.ReserveIdempotencyKey(ctx, "key", "first", time.Hour)
+	if err != nil || reserved || !record.Completed || record.Fingerprint != "final" || record.Status != 201 ||
+		record.Header["Location"] != "/1" || string(record.Body) != `{"id":1}` {
+		t.Fatalf("ReserveIdempotencyKey of a completed key = %+v, %v, %v", record, reserved, err)
+	}
+
+	if err := repo.ReleaseIdempotencyKey(ctx, "key"); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey: %v", err)
+	}
+
+	if _, reserved, err := repo.ReserveIdempotencyKey(ctx, "key", "first", -time.Second); err != nil || !reserved {
+		t.Fatalf("ReserveIdempotencyKey of a released key = %v, %v", reserved, err)
+	}
+
+	// The key above has already expired.
+	if _, reserved, err := repo.ReserveIdempotencyKey(ctx, "key", "third", time.Hour); err != nil || !reserved {
+		t.Fatalf("ReserveIdempotencyKey of an expired key = %v, %v", reserved, err)
+	}
+}
+
+// testIdempotencyChurn reserves and releases one key from several goroutines.
+// A key that is not reserved always comes with the record that holds it, even
+// when it is released in the middle of the reservation.
+func testIdempotencyChurn(t *testing.T, repo repository.Idempotency) {
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 50 {
+				record, reserved, err := repo.ReserveIdempotencyKey(ctx, "key", "fingerprint", time.Hour)
+
+				switch {
+				case errors.Is(err, models.ErrConflict):
+				case err != nil:
+					t.Errorf("ReserveIdempotencyKey: %v", err)
+					return
+				case reserved:
+					if err := repo.ReleaseIdempotencyKey(ctx, "key"); err != nil {
+						t.Errorf("ReleaseIdempotencyKey: %v", err)
+						return
+					}
+				case record.Fingerprint != "fingerprint":
+					t.Errorf("ReserveIdempotencyKey of a key held by another request = %+v, want its record", record)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,538 @@
+// Package repotest contains the conformance suite shared by every
+// repository implementation.
+//
+// A backend runs it from its own test with a factory that returns an empty
+// repository for every subtest:
+//
+//	repotest.TestMusic(t, func(t *testing.T) *repository.Repository {
+//		return repository.NewMemoryRepository()
+//	})
+package repotest
+
+import (
+	"context"
+	"errors"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+	"time"
+)
+
+var fixtures = []models.MusicInfo{
+	{
+		Group:      "Muse",
+		Song:       "Supermassive Black Hole",
+		RelaseDate: models.NewDate(2006, time.July, 16),
+		Text:       "Ooh baby, don't you know I suffer?\n\nOoh\n\nYou set my soul alight",
+		Link:       "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+
+		EnrichmentStatus: models.EnrichmentEnriched,
+	},
+	{
+		Group:      "Muse",
+		Song:       "Uprising",
+		RelaseDate: models.NewDate(2009, time.September, 7),
+		Text:       "Paranoia is in bloom",
+		Link:       "https://www.youtube.com/watch?v=w8KQmps-Sog",
+
+		EnrichmentStatus: models.EnrichmentEnriched,
+	},
+	{
+		Group:      "Radiohead",
+		Song:       "Creep",
+		RelaseDate: models.NewDate(1992, time.September, 21),
+		Text:       "When you were here before",
+		Link:       "https://www.youtube.com/watch?v=XFkzRNyygfk",
+
+		EnrichmentStatus: models.EnrichmentEnriched,
+	},
+}
+
+// Factory returns an empty repository.
+type Factory func(t *testing.T) *repository.Repository
+
+// TestMusic runs the conformance suite against repositories built by newRepo.
+func TestMusic(t *testing.T, newRepo Factory) {
+	t.Run("Filters", func(t *testing.T) { testFilters(t, newRepo(t).Music) })
+	t.Run("Pagination", func(t *testing.T) { testPagination(t, newRepo(t).Music) })
+	t.Run("Get", func(t *testing.T) { testGet(t, newRepo(t).Music) })
+	t.Run("Verses", func(t *testing.T) { testVerses(t, newRepo(t).Music) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newRepo(t).Music) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newRepo(t).Music) })
+	t.Run("Trash", func(t *testing.T) { testTrash(t, newRepo(t).Music) })
+	t.Run("History", func(t *testing.T) { testHistory(t, newRepo(t).Music) })
+	t.Run("Batch", func(t *testing.T) { testBatch(t, newRepo(t).Music) })
+	t.Run("Export", func(t *testing.T) { testExport(t, newRepo(t).Music) })
+	t.Run("Duplicates", func(t *testing.T) { testDuplicates(t, newRepo(t)) })
+	t.Run("Enrichment", func(t *testing.T) { testEnrichment(t, newRepo(t)) })
+	t.Run("ClaimEnrichment", func(t *testing.T) { testClaimEnrichment(t, newRepo(t)) })
+	t.Run("Artists", func(t *testing.T) { testArtists(t, newRepo(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newRepo(t)) })
+	t.Run("Idempotency", func(t *testing.T) { testIdempotency(t, newRepo(t).Idempotency) })
+	t.Run("IdempotencyChurn", func(t *testing.T) { testIdempotencyChurn(t, newRepo(t).Idempotency) })
+	t.Run("APIKeys", func(t *testing.T) { testAPIKeys(t, newRepo(t).APIKey) })
+	t.Run("RateLimit", func(t *testing.T) { testRateLimit(t, newRepo(t).RateLimit) })
+}
+
+func testFilters(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	seed(t, repo)
+
+	contains := func(value string) models.TextFilter {
+		return models.TextFilter{Value: value, Mode: models.MatchContains}
+	}
+	exact := func(value string) models.TextFilter {
+		return models.TextFilter{Value: value, Mode: models.MatchExact}
+	}
+
+	tests := []struct {
+		name   string
+		filter models.MusicFilter
+		want   []string
+	}{
+		{name: "no filters", want: []string{"Supermassive Black Hole", "Uprising", "Creep"}},
+		{
+			name:   "group is case insensitive",
+			filter: models.MusicFilter{Group: contains("mUs")},
+			want:   []string{"Supermassive Black Hole", "Uprising"},
+		},
+		{name: "song substring", filter: models.MusicFilter{Song: contains("rising")}, want: []string{"Uprising"}},
+		{name: "exact song", filter: models.MusicFilter{Song: exact("uprising")}, want: []string{"Uprising"}},
+		{name: "exact song is not a substring", filter: models.MusicFilter{Song: exact("rising")}},
+		{name: "like wildcards are literal", filter: models.MusicFilter{Song: contains("%")}},
+		{name: "release date", filter: models.MusicFilter{ReleaseDate: models.NewDate(2009, time.September, 7)}, want: []string{"Uprising"}},
+		{
+			name:   "release date range",
+			filter: models.MusicFilter{ReleasedAfter: models.NewDate(1992, time.September, 21), ReleasedBefore: models.NewDate(2006, time.July, 16)},
+			want:   []string{"Supermassive Black Hole", "Creep"},
+		},
+		{name: "text substring", filter: models.MusicFilter{Text: contains("SOUL")}, want: []string{"Supermassive Black Hole"}},
+		{
+			name:   "filters are combined",
+			filter: models.MusicFilter{Group: exact("muse"), Text: contains("paranoia")},
+			want:   []string{"Uprising"},
+		},
+		{name: "nothing matches", filter: models.MusicFilter{Group: contains("Queen")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Limit = 10
+
+			got, err := repo.GetMusics(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("GetMusics: %v", err)
+			}
+
+			assertSongs(t, got, tt.want)
+		})
+	}
+}
+
+func testPagination(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	seed(t, repo)
+
+	got, err := repo.GetMusics(ctx, models.MusicFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+	assertSongs(t, got, []string{"Supermassive Black Hole", "Uprising"})
+
+	got, err = repo.GetMusics(ctx, models.MusicFilter{Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+	assertSongs(t, got, []string{"Creep"})
+
+	got, err = repo.GetMusics(ctx, models.MusicFilter{Limit: 2, Offset: 3})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+	assertSongs(t, got, nil)
+
+	first, err := repo.GetMusics(ctx, models.MusicFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+
+	// The offset is ignored once a cursor is given.
+	got, err = repo.GetMusics(ctx, models.MusicFilter{Limit: 2, Offset: 5, After: &models.Cursor{ID: first[0].ID}})
+	if err != nil {
+		t.Fatalf("GetMusics after cursor: %v", err)
+	}
+	assertSongs(t, got, []string{"Uprising", "Creep"})
+
+	sort := []models.SortField{{Field: models.SortGroup}, {Field: models.SortReleaseDate, Desc: true}}
+
+	got, err = repo.GetMusics(ctx, models.MusicFilter{Sort: sort, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetMusics sorted: %v", err)
+	}
+	assertSongs(t, got, []string{"Uprising", "Supermassive Black Hole", "Creep"})
+
+	keys := models.MusicFilter{Sort: sort}.SortKeys()
+	after := models.NewCursor(got[1], keys)
+
+	got, err = repo.GetMusics(ctx, models.MusicFilter{Sort: sort, Limit: 10, After: &after})
+	if err != nil {
+		t.Fatalf("GetMusics sorted after cursor: %v", err)
+	}
+	assertSongs(t, got, []string{"Creep"})
+}
+
+func testGet(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	musics := seed(t, repo)
+
+	for _, want := range musics {
+		got, err := repo.GetMusic(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetMusic(%d): %v", want.ID, err)
+		}
+		if got != want {
+			t.Errorf("GetMusic(%d) = %+v, want %+v", want.ID, got, want)
+		}
+	}
+
+	if _, err := repo.GetMusic(ctx, musics[2].ID+100); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetMusic of a missing song: got %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+func testVerses(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	musics := seed(t, repo)
+
+	lyrics, err := repo.GetSongLyricsByVerses(ctx, musics[0].ID, 2, 2)
+	if err != nil {
+		t.Fatalf("GetSongLyricsByVerses: %v", err)
+	}
+	assertStrings(t, lyrics.Verses, []string{"Ooh", "You set my soul alight"})
+
+	if lyrics.TotalVerses != 3 {
+		t.Errorf("GetSongLyricsByVerses counted %d verses, want 3", lyrics.TotalVerses)
+	}
+
+	lyrics, err = repo.GetSongLyricsByVerses(ctx, musics[0].ID, 3, 5)
+	if err != nil {
+		t.Fatalf("GetSongLyricsByVerses: %v", err)
+	}
+	assertStrings(t, lyrics.Verses, []string{"You set my soul alight"})
+
+	lyrics, err = repo.GetSongLyricsByVerses(ctx, musics[0].ID, 10, 1)
+	if err != nil {
+		t.Fatalf("GetSongLyricsByVerses past the last verse: %v", err)
+	}
+	assertStrings(t, lyrics.Verses, []string{})
+
+	// Real newlines and CRLF separate verses, a literal \n is text.
+	added, err := repo.AddMusic(ctx, models.MusicInfo{
+		Group: "Muse",
+		Song:  "Madness",
+		Text:  "I can't get these memories\r\nOut of my mind\r\n\r\nAnd some kind of madness\n\n\nHas started to evolve\\n\\nC:\\new",
+	})
+	if err != nil {
+		t.Fatalf("AddMusic: %v", err)
+	}
+
+	lyrics, err = repo.GetSongLyricsByVerses(ctx, added.ID, 1, 5)
+	if err != nil {
+		t.Fatalf("GetSongLyricsByVerses: %v", err)
+	}
+	assertStrings(t, lyrics.Verses, []string{
+		"I can't get these memories\nOut of my mind", "And some kind of madness", "Has started to evolve\\n\\nC:\\new",
+	})
+
+	want := "I can't get these memories\nOut of my mind\n\nAnd some kind of madness\n\nHas started to evolve\\n\\nC:\\new"
+	if got := find(t, repo, added.ID); got.Text != want || added.Text != want {
+		t.Errorf("AddMusic returned text %q, stored %q, want %q", added.Text, got.Text, want)
+	}
+
+	updated, err := repo.UpdateMusic(ctx, added.ID, models.MusicUpdate{Text: models.Set("a\n\nb\r\n\r\nc")}, 0)
+	if err != nil {
+		t.Fatalf("UpdateMusic: %v", err)
+	}
+	if got := find(t, repo, added.ID); got.Text != "a\n\nb\n\nc" || updated.Text != got.Text {
+		t.Errorf("UpdateMusic returned text %q, stored %q, want %q", updated.Text, got.Text, "a\n\nb\n\nc")
+	}
+
+	if _, err := repo.GetSongLyricsByVerses(ctx, musics[2].ID+100, 1, 1); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetSongLyricsByVerses of a missing song: got %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+func testUpdate(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	musics := seed(t, repo)
+
+	if _, err := repo.UpdateMusic(ctx, musics[1].ID, models.MusicUpdate{}, 0); !errors.Is(err, models.ErrNoUpdates) {
+		t.Errorf("UpdateMusic without updates: got %v, want %v", err, models.ErrNoUpdates)
+	}
+
+	_, err := repo.UpdateMusic(ctx, musics[2].ID+100, models.MusicUpdate{Song: models.Set("Resistance")}, 0)
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("UpdateMusic of a missing song: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	updates := models.MusicUpdate{Song: models.Set("Resistance"), Link: models.Set("https://example.com")}
+
+	updated, err := repo.UpdateMusic(ctx, musics[1].ID, updates, musics[1].Version)
+	if err != nil {
+		t.Fatalf("UpdateMusic: %v", err)
+	}
+	if updated.Version != musics[1].Version+1 {
+		t.Errorf("UpdateMusic left version %d, want %d", updated.Version, musics[1].Version+1)
+	}
+
+	// The version read before the update is stale now.
+	_, err = repo.UpdateMusic(ctx, musics[1].ID, models.MusicUpdate{Song: models.Set("Hysteria")}, musics[1].Version)
+	if !errors.Is(err, models.ErrPreconditionFailed) {
+		t.Errorf("UpdateMusic of a stale version: got %v, want %v", err, models.ErrPreconditionFailed)
+	}
+
+	got := find(t, repo, musics[1].ID)
+	if updated != got {
+		t.Errorf("UpdateMusic returned %+v, stored %+v", updated, got)
+	}
+
+	want := musics[1]
+	want.Song = "Resistance"
+	want.Link = "https://example.com"
+
+	if got.Group != want.Group || got.Song != want.Song || got.Text != want.Text || got.Link != want.Link {
+		t.Errorf("after UpdateMusic got %+v, want %+v", got, want)
+	}
+
+	// Null clears a field, except the group and the title.
+	cleared, err := repo.UpdateMusic(ctx, musics[1].ID, models.MusicUpdate{
+		RelaseDate: models.Clear[models.Date](),
+		Text:       models.Clear[string](),
+		Link:       models.Clear[string](),
+	}, 0)
+	if err != nil {
+		t.Fatalf("UpdateMusic: %v", err)
+	}
+	if !cleared.RelaseDate.IsZero() || cleared.Text != "" || cleared.Link != "" || cleared.Song != want.Song {
+		t.Errorf("after clearing got %+v, want %q without date, text and link", cleared, want.Song)
+	}
+	if got := find(t, repo, musics[1].ID); got != cleared {
+		t.Errorf("UpdateMusic returned %+v, stored %+v", cleared, got)
+	}
+
+	for _, updates := range []models.MusicUpdate{{Group: models.Clear[string]()}, {Song: models.Clear[string]()}} {
+		if _, err := repo.UpdateMusic(ctx, musics[1].ID, updates, 0); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("UpdateMusic(%+v): got %v, want %v", updates, err, models.ErrValidation)
+		}
+	}
+}
+
+func testDelete(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	musics := seed(t, repo)
+
+	err := repo.DeleteMusic(ctx, musics[0].ID, musics[0].Version+1)
+	if !errors.Is(err, models.ErrPreconditionFailed) {
+		t.Errorf("DeleteMusic of a stale version: got %v, want %v", err, models.ErrPreconditionFailed)
+	}
+
+	if err := repo.DeleteMusic(ctx, musics[0].ID, musics[0].Version); err != nil {
+		t.Fatalf("DeleteMusic: %v", err)
+	}
+
+	got, err := repo.GetMusics(ctx, models.MusicFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+	assertSongs(t, got, []string{"Uprising", "Creep"})
+
+	if err := repo.DeleteMusic(ctx, musics[0].ID, 0); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("DeleteMusic of a deleted song: got %v, want %v", err, models.ErrNotFound)
+	}
+}
+
+// seed stores the fixtures and returns them with the IDs assigned by repo.
+func testBatch(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	seed(t, repo)
+
+	batch := []models.MusicInfo{
+		{Group: "MUSE", Song: "uprising"},
+		{Group: "Muse", Song: "Hysteria", Text: "It's bugging me\n\nGrating me", EnrichmentStatus: models.EnrichmentEnriched},
+		{Group: "Queen", Song: "Bohemian Rhapsody", RelaseDate: models.NewDate(1975, time.October, 31)},
+		{Group: "Muse", Song: "Hysteria"},
+	}
+
+	IDs, err := repo.AddMusics(ctx, batch)
+	if err != nil {
+		t.Fatalf("AddMusics: %v", err)
+	}
+
+	if len(IDs) != len(batch) || IDs[0] != 0 || IDs[1] == 0 || IDs[2] == 0 || IDs[3] != 0 {
+		t.Fatalf("AddMusics returned %v, want IDs only for the new songs", IDs)
+	}
+
+	hysteria := find(t, repo, IDs[1])
+	if hysteria.Song != "Hysteria" || hysteria.EnrichmentStatus != models.EnrichmentEnriched {
+		t.Fatalf("got %+v", hysteria)
+	}
+
+	lyrics, err := repo.GetSongLyricsByVerses(ctx, IDs[1], 2, 1)
+	if err != nil {
+		t.Fatalf("GetSongLyricsByVerses: %v", err)
+	}
+	assertStrings(t, lyrics.Verses, []string{"Grating me"})
+
+	queen := find(t, repo, IDs[2])
+	if queen.Group != "Queen" || queen.RelaseDate != models.NewDate(1975, time.October, 31) || queen.EnrichmentStatus != models.EnrichmentPending {
+		t.Fatalf("got %+v", queen)
+	}
+
+	IDs, err = repo.AddMusics(ctx, nil)
+	if err != nil || len(IDs) != 0 {
+		t.Fatalf("AddMusics(nil) = %v, %v", IDs, err)
+	}
+}
+
+func testExport(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	seed(t, repo)
+
+	var got []models.MusicInfo
+
+	filter := models.MusicFilter{
+		Group: models.TextFilter{Value: "muse"},
+		Sort:  []models.SortField{{Field: models.SortSong, Desc: true}},
+		Limit: 1,
+	}
+
+	err := repo.ExportMusics(ctx, filter, func(music models.MusicInfo) error {
+		got = append(got, music)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportMusics: %v", err)
+	}
+	assertSongs(t, got, []string{"Uprising", "Supermassive Black Hole"})
+
+	errStop := errors.New("stop")
+	calls := 0
+
+	err = repo.ExportMusics(ctx, models.MusicFilter{}, func(models.MusicInfo) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Fatalf("ExportMusics did not stop on the yield error: %v after %d calls", err, calls)
+	}
+}
+
+func testDuplicates(t *testing.T, repos *repository.Repository) {
+	ctx := context.Background()
+	musics := seed(t, repos.Music)
+
+	_, err := repos.AddMusic(ctx, models.MusicInfo{Group: "mUSE", Song: "UPRISING"})
+
+	var exists *models.SongExistsError
+	if !errors.As(err, &exists) || exists.ID != musics[1].ID || !errors.Is(err, models.ErrConflict) {
+		t.Fatalf("AddMusic of a duplicate: got %v, want song %d exists", err, musics[1].ID)
+	}
+
+	// A new song of an existing artist keeps the stored spelling.
+	added, err := repos.AddMusic(ctx, models.MusicInfo{Group: "muse", Song: "Hysteria"})
+	if err != nil {
+		t.Fatalf("AddMusic: %v", err)
+	}
+	if added.Group != "Muse" {
+		t.Errorf("AddMusic returned group %q, want Muse", added.Group)
+	}
+	if got := find(t, repos.Music, added.ID).Group; got != "Muse" {
+		t.Errorf("AddMusic stored group %q, want Muse", got)
+	}
+
+	_, err = repos.UpdateMusic(ctx, added.ID, models.MusicUpdate{Song: models.Set("uprising")}, 0)
+	if !errors.Is(err, models.ErrConflict) {
+		t.Errorf("UpdateMusic to a duplicate: got %v, want %v", err, models.ErrConflict)
+	}
+
+	if _, err := repos.AddArtist(ctx, "RADIOHEAD"); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("AddArtist of an existing name in another case: got %v, want %v", err, models.ErrConflict)
+	}
+}
+
+func seed(t *testing.T, repo repository.Music) []models.MusicInfo {
+	t.Helper()
+
+	ctx := context.Background()
+
+	var added []models.MusicInfo
+
+	for _, music := range fixtures {
+		music, err := repo.AddMusic(ctx, music)
+		if err != nil {
+			t.Fatalf("AddMusic: %v", err)
+		}
+
+		added = append(added, music)
+	}
+
+	stored, err := repo.GetMusics(ctx, models.MusicFilter{Limit: len(fixtures)})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+
+	if len(stored) != len(fixtures) {
+		t.Fatalf("stored %d songs, want %d", len(stored), len(fixtures))
+	}
+
+	for i, music := range stored {
+		if music != added[i] {
+			t.Fatalf("AddMusic returned %+v, stored %+v", added[i], music)
+		}
+	}
+
+	return stored
+}
+
+func find(t *testing.T, repo repository.Music, ID int) models.MusicInfo {
+	t.Helper()
+
+	musics, err := repo.GetMusics(context.Background(), models.MusicFilter{Limit: 100})
+	if err != nil {
+		t.Fatalf("GetMusics: %v", err)
+	}
+
+	for _, music := range musics {
+		if music.ID == ID {
+			return music
+		}
+	}
+
+	t.Fatalf("song %d not found", ID)
+	return models.MusicInfo{}
+}
+
+func assertSongs(t *testing.T, got []models.MusicInfo, want []string) {
+	t.Helper()
+
+	songs := make([]string, 0, len(got))
+	for _, music := range got {
+		songs = append(songs, music.Song)
+	}
+
+	assertStrings(t, songs, want)
+}
+
+func assertStrings(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package repotest
+
+import (
+	"context"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+	"time"
+)
+
+func testRateLimit(t *testing.T, repo repository.RateLimit) {
+	ctx := context.Background()
+	limit := models.RateLimit{Requests: 2, Per: 10 * time.Second}
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	take := func(key string, at time.Duration) models.RateLimitResult {
+		t.Helper()
+
+		result, err := repo.TakeRateLimitToken(ctx, key, limit, now.Add(at))
+		if err != nil {
+			t.Fatalf("TakeRateLimitToken(%q): %v", key, err)
+		}
+
+		return result
+	}
+
+	if got := take("a", 0); !got.Allowed || got.Remaining != 1 || got.Reset != 5*time.Second {
+		t.Fatalf("first token = %+v", got)
+	}
+
+	if got := take("a", 0); !got.Allowed || got.Remaining != 0 {
+		t.Fatalf("second token = %+v", got)
+	}
+
+	if got := take("a", time.Second); got.Allowed || got.RetryAfter != 4*time.Second {
+		t.Fatalf("token of an empty bucket = %+v", got)
+	}
+
+	if got := take("b", time.Second); !got.Allowed {
+		t.Fatalf("token of another key = %+v", got)
+	}
+
+	// A replica whose clock is behind neither refills nor drains the bucket.
+	if got := take("b", 0); !got.Allowed || got.Remaining != 0 {
+		t.Fatalf("token with a clock behind = %+v", got)
+	}
+
+	if got := take("b", 0); got.Allowed || got.RetryAfter != 5*time.Second {
+		t.Fatalf("token of an empty bucket with a clock behind = %+v", got)
+	}
+
+	// A token is back 5 seconds after the bucket was emptied.
+	if got := take("a", 5*time.Second); !got.Allowed || got.Remaining != 0 {
+		t.Fatalf("token after a refill = %+v", got)
+	}
+
+	if got := take("a", time.Minute); !got.Allowed || got.Remaining != 1 {
+		t.Fatalf("token of an idle bucket = %+v", got)
+	}
+}
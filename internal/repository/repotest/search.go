@@ -0,0 +1,49 @@
+package repotest
+
+import (
+	"context"
+	"music/internal/models"
+	"music/internal/repository"
+	"strings"
+	"testing"
+)
+
+func testSearch(t *testing.T, repos *repository.Repository) {
+	ctx := context.Background()
+	seed(t, repos.Music)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "paranoia", want: []string{"Uprising"}},
+		{query: "radiohead", want: []string{"Creep"}},
+		{query: "muse uprising", want: []string{"Uprising"}},
+		{query: "queen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := repos.SearchMusics(ctx, models.SearchQuery{Query: tt.query, Limit: 10})
+			if err != nil {
+				t.Fatalf("SearchMusics: %v", err)
+			}
+
+			var got []models.MusicInfo
+			for _, result := range results {
+				got = append(got, result.MusicInfo)
+			}
+
+			assertSongs(t, got, tt.want)
+		})
+	}
+
+	results, err := repos.SearchMusics(ctx, models.SearchQuery{Query: "soul", Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchMusics: %v", err)
+	}
+
+	if len(results) != 1 || !strings.Contains(results[0].Snippet, "<b>soul</b>") {
+		t.Errorf("SearchMusics got %+v, want a snippet with <b>soul</b>", results)
+	}
+}
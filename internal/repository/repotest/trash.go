@@ -0,0 +1,114 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+	"time"
+)
+
+func testTrash(t *testing.T, repo repository.Music) {
+	ctx := context.Background()
+	musics := seed(t, repo)
+
+	if err := repo.DeleteMusic(ctx, musics[0].ID, 0); err != nil {
+		t.Fatalf("DeleteMusic: %v", err)
+	}
+
+	// A deleted song is left out of everything but the trash.
+	if _, err := repo.GetMusic(ctx, musics[0].ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetMusic of a deleted song: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	_, err := repo.UpdateMusic(ctx, musics[0].ID, models.MusicUpdate{Link: models.Set("https://example.com")}, 0)
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("UpdateMusic of a deleted song: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	if _, err := repo.GetSongLyricsByVerses(ctx, musics[0].ID, 1, 1); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("GetSongLyricsByVerses of a deleted song: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	trash, err := repo.GetDeletedMusics(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetDeletedMusics: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != musics[0].ID || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("GetDeletedMusics = %+v, want song %d", trash, musics[0].ID)
+	}
+
+	restored, err := repo.RestoreMusic(ctx, musics[0].ID)
+	if err != nil {
+		t.Fatalf("RestoreMusic: %v", err)
+	}
+	if restored.Song != musics[0].Song || restored.Version <= musics[0].Version {
+		t.Errorf("RestoreMusic = %+v, want %q at a newer version", restored, musics[0].Song)
+	}
+	if got := find(t, repo, musics[0].ID); got != restored {
+		t.Errorf("after RestoreMusic got %+v, want %+v", got, restored)
+	}
+
+	if _, err := repo.RestoreMusic(ctx, musics[0].ID); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("RestoreMusic of a song not in the trash: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	// The title of a deleted song is free, restoring it then conflicts.
+	if err := repo.DeleteMusic(ctx, musics[0].ID, 0); err != nil {
+		t.Fatalf("DeleteMusic: %v", err)
+	}
+
+	readded, err := repo.AddMusic(ctx, models.MusicInfo{Group: musics[0].Group, Song: musics[0].Song})
+	if err != nil {
+		t.Fatalf("AddMusic of a deleted song: %v", err)
+	}
+
+	if _, err := repo.RestoreMusic(ctx, musics[0].ID); !errors.Is(err, models.ErrConflict) {
+		t.Errorf("RestoreMusic over a new song: got %v, want %v", err, models.ErrConflict)
+	}
+
+	purged, err := repo.PurgeMusics(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeMusics: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("PurgeMusics removed %d songs deleted after the cutoff", purged)
+	}
+
+	before, err := repo.GetMusicHistory(ctx, musics[0].ID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetMusicHistory: %v", err)
+	}
+
+	purged, err = repo.PurgeMusics(models.WithAudit(ctx, models.Audit{Actor: "purger"}), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeMusics: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeMusics removed %d songs, want 1", purged)
+	}
+
+	// The history of a purged song is kept and ends with the purge.
+	history, err := repo.GetMusicHistory(ctx, musics[0].ID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetMusicHistory of a purged song: %v", err)
+	}
+	if len(history) != len(before)+1 {
+		t.Fatalf("GetMusicHistory of a purged song = %+v, want %d revisions", history, len(before)+1)
+	}
+	if last := history[0]; last.Action != models.RevisionPurge || last.Rev != before[0].Rev+1 ||
+		last.Old != nil || last.New != nil || last.Actor != "purger" {
+		t.Errorf("last revision of a purged song = %+v, want a purge by purger at rev %d", last, before[0].Rev+1)
+	}
+
+	trash, err = repo.GetDeletedMusics(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetDeletedMusics: %v", err)
+	}
+	if len(trash) != 0 {
+		t.Errorf("GetDeletedMusics after purge = %+v, want none", trash)
+	}
+
+	find(t, repo, readded.ID)
+}
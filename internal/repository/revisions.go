@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const selectRevisions = `
+	SELECT song_id, rev, action, old_value, new_value, COALESCE(actor, ''), COALESCE(request_id, ''), created_at
+	FROM song_revisions
+`
+
+// revisionRow is a revision in the shape read by jsonb_to_recordset in
+// insertRevisions.
+type revisionRow struct {
+	SongID int               `json:"song_id"`
+	Rev    int               `json:"rev"`
+	Action string            `json:"action"`
+	Old    *models.MusicInfo `json:"old_value,omitempty"`
+	New    *models.MusicInfo `json:"new_value,omitempty"`
+}
+
+// insertRevisions records changes in the history of their songs, with the
+// actor and the request ID carried by ctx.
+func insertRevisions(ctx context.Context, tx *sql.Tx, revisions []models.Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	rows := make([]revisionRow, 0, len(revisions))
+	for _, revision := range revisions {
+		rows = append(rows, revisionRow{
+			SongID: revision.SongID,
+			Rev:    revision.Rev,
+			Action: revision.Action,
+			Old:    revision.Old,
+			New:    revision.New,
+		})
+	}
+
+	values, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO song_revisions (song_id, rev, action, old_value, new_value, actor, request_id)
+		SELECT r.song_id, r.rev, r.action, r.old_value, r.new_value, NULLIF($2, ''), NULLIF($3, '')
+		FROM jsonb_to_recordset($1::JSONB)
+		     AS r (song_id INT, rev INT, action TEXT, old_value JSONB, new_value JSONB);
+	`
+
+	audit := models.AuditFrom(ctx)
+
+	if _, err := tx.ExecContext(ctx, query, values, audit.Actor, audit.RequestID); err != nil {
+		return fmt.Errorf("failed to insert revisions: %w", err)
+	}
+
+	return nil
+}
+
+// insertBatchRevisions records the insertion of the new songs of a batch,
+// songs with ID 0 are skipped.
+func insertBatchRevisions(ctx context.Context, tx *sql.Tx, IDs []int) error {
+	var songIDs []int64
+
+	for _, ID := range IDs {
+		if ID != 0 {
+			songIDs = append(songIDs, int64(ID))
+		}
+	}
+
+	if len(songIDs) == 0 {
+		return nil
+	}
+
+	rows, err := tx.QueryContext(ctx, selectMusics+"WHERE s.id = ANY($1);", pq.Array(songIDs))
+	if err != nil {
+		return fmt.Errorf("failed to fetch new songs: %w", err)
+	}
+
+	musics, err := scanMusics(rows)
+	if err != nil {
+		return err
+	}
+
+	revisions := make([]models.Revision, 0, len(musics))
+	for i := range musics {
+		revisions = append(revisions, models.Revision{
+			SongID: musics[i].ID,
+			Rev:    musics[i].Version,
+			Action: models.RevisionInsert,
+			New:    &musics[i],
+		})
+	}
+
+	return insertRevisions(ctx, tx, revisions)
+}
+
+// lockArtistSongs locks the songs of the artist for the rest of tx, those in
+// the trash included, and returns them as they are before the change.
+func lockArtistSongs(ctx context.Context, tx *sql.Tx, artistID int) ([]models.MusicInfo, error) {
+	rows, err := tx.QueryContext(ctx, selectMusics+"WHERE s.artist_id = $1 ORDER BY s.id FOR UPDATE OF s;", artistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock songs: %w", err)
+	}
+
+	return scanMusics(rows)
+}
+
+// insertUpdateRevisions records the songs locked as olds as updated, with
+// the values they have now in tx.
+func insertUpdateRevisions(ctx context.Context, tx *sql.Tx, olds []models.MusicInfo) error {
+	if len(olds) == 0 {
+		return nil
+	}
+
+	songIDs := make([]int64, len(olds))
+	for i, old := range olds {
+		songIDs[i] = int64(old.ID)
+	}
+
+	rows, err := tx.QueryContext(ctx, selectMusics+"WHERE s.id = ANY($1) ORDER BY s.id;", pq.Array(songIDs))
+	if err != nil {
+		return fmt.Errorf("failed to fetch changed songs: %w", err)
+	}
+
+	musics, err := scanMusics(rows)
+	if err != nil {
+		return err
+	}
+
+	revisions := make([]models.Revision, 0, len(musics))
+	for i := range musics {
+		revisions = append(revisions, models.Revision{
+			SongID: musics[i].ID,
+			Rev:    musics[i].Version,
+			Action: models.RevisionUpdate,
+			Old:    &olds[i],
+			New:    &musics[i],
+		})
+	}
+
+	return insertRevisions(ctx, tx, revisions)
+}
+
+// lockMusic locks the song for the rest of tx and returns it as it is before
+// the change. A non-zero version has to be the version of the song, else
+// the change fails with models.ErrPreconditionFailed.
+func lockMusic(ctx context.Context, tx *sql.Tx, ID, version int) (models.MusicInfo, error) {
+	var current int
+
+	err := tx.QueryRowContext(
+		ctx, "SELECT version FROM songs WHERE id = $1 AND deleted_at IS NULL FOR UPDATE;", ID,
+	).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.MusicInfo{}, fmt.Errorf("%w: song %d", models.ErrNotFound, ID)
+	}
+	if err != nil {
+		return models.MusicInfo{}, fmt.Errorf("failed to lock song: %w", err)
+	}
+
+	if version != 0 && version != current {
+		return models.MusicInfo{}, fmt.Errorf(
+			"%w: song %d is at version %d", models.ErrPreconditionFailed, ID, current,
+		)
+	}
+
+	return getMusic(ctx, tx, ID)
+}
+
+// getRevision returns the revision rev of the song.
+func getRevision(ctx context.Context, tx *sql.Tx, ID, rev int) (models.Revision, error) {
+	revision, err := scanRevision(tx.QueryRowContext(ctx, selectRevisions+"WHERE song_id = $1 AND rev = $2;", ID, rev))
+	if errors.Is(err, sql.ErrNoRows) {
+		return revision, fmt.Errorf("%w: revision %d of song %d", models.ErrNotFound, rev, ID)
+	}
+
+	return revision, err
+}
+
+func scanRevision(row rowScanner) (models.Revision, error) {
+	var (
+		revision           models.Revision
+		oldValue, newValue []byte
+	)
+
+	err := row.Scan(
+		&revision.SongID,
+		&revision.Rev,
+		&revision.Action,
+		&oldValue,
+		&newValue,
+		&revision.Actor,
+		&revision.RequestID,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		return revision, err
+	}
+
+	if revision.Old, err = decodeRevisionValue(oldValue); err != nil {
+		return revision, err
+	}
+
+	if revision.New, err = decodeRevisionValue(newValue); err != nil {
+		return revision, err
+	}
+
+	return revision, nil
+}
+
+// decodeRevisionValue decodes a song stored in a revision, NULL is nil.
+func decodeRevisionValue(value []byte) (*models.MusicInfo, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	var music models.MusicInfo
+
+	if err := json.Unmarshal(value, &music); err != nil {
+		return nil, fmt.Errorf("failed to decode revision: %w", err)
+	}
+
+	return &music, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"music/internal/models"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchMusics approximates the Postgres full-text search with the simple
+// dictionary: every query term must appear as a word in the title, group or
+// lyrics, and matches in the title weigh more than in the group, which weigh
+// more than in lyrics.
+func (r *musicMemory) SearchMusics(ctx context.Context, query models.SearchQuery) ([]models.SearchResult, error) {
+	terms := searchTerms(query.Query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []models.SearchResult
+
+	for _, music := range r.musics {
+		if music.deleted() {
+			continue
+		}
+
+		title, group, lyrics := searchTerms(music.Song), searchTerms(music.Group), searchTerms(music.Text)
+
+		var rank float64
+		matched := true
+
+		for _, term := range terms {
+			hits := 1.0*countWord(title, term) + 0.4*countWord(group, term) + 0.1*countWord(lyrics, term)
+			if hits == 0 {
+				matched = false
+				break
+			}
+
+			rank += hits
+		}
+
+		if !matched {
+			continue
+		}
+
+		matches = append(matches, models.SearchResult{
+			MusicInfo: music.MusicInfo,
+			Rank:      rank,
+			Snippet:   snippet(music.Text, terms),
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Rank > matches[j].Rank
+	})
+
+	matches = matches[min(query.Offset, len(matches)):]
+	if len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+
+	return matches, nil
+}
+
+// SetSearchLanguage is a no-op, the in-memory search has no dictionaries.
+func (r *musicMemory) SetSearchLanguage(ctx context.Context, language string) error {
+	return nil
+}
+
+func searchTerms(s string) []string {
+	s = strings.NewReplacer(`\n`, " ", `\r`, " ").Replace(s)
+
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func countWord(words []string, term string) float64 {
+	var n float64
+
+	for _, word := range words {
+		if word == term {
+			n++
+		}
+	}
+
+	return n
+}
+
+// snippet returns the first verse that contains a term with the matching
+// words wrapped in <b></b>.
+func snippet(text string, terms []string) string {
+	for _, verse := range splitVerses(text) {
+		words := strings.Fields(verse)
+		found := false
+
+		for i, word := range words {
+			tokens := searchTerms(word)
+
+			if len(tokens) > 0 && slices.Contains(terms, tokens[0]) {
+				words[i] = "<b>" + word + "</b>"
+				found = true
+			}
+		}
+
+		if found {
+			return strings.Join(words, " ")
+		}
+	}
+
+	return ""
+}
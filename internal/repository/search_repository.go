@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"music/internal/models"
+	"sync"
+
+	"github.com/lib/pq"
+)
+
+const defaultSearchLanguage = "simple"
+
+type Search interface {
+	// SearchMusics returns the songs matching a web search style query
+	// ordered by relevance.
+	SearchMusics(ctx context.Context, query models.SearchQuery) ([]models.SearchResult, error)
+	// SetSearchLanguage sets the text search configuration (dictionary) used
+	// to index songs and parse queries, reindexing songs when it changes.
+	SetSearchLanguage(ctx context.Context, language string) error
+}
+
+type searchPostgres struct {
+	db *sql.DB
+
+	mu       sync.RWMutex
+	language string
+}
+
+func newSearchPostgres(db *sql.DB) Search {
+	return &searchPostgres{db: db, language: defaultSearchLanguage}
+}
+
+func (r *searchPostgres) SearchMusics(ctx context.Context, query models.SearchQuery) ([]models.SearchResult, error) {
+	sqlQuery := `
+		WITH q AS (SELECT websearch_to_tsquery($1::REGCONFIG, $2) AS query)
+		SELECT s.id, a.name, s.song, s.release_date, l.text, s.link, s.enrichment_status, s.version,
+		       ts_rank(s.search_vector, q.query) AS rank,
+		       ts_headline(
+		           $1::REGCONFIG,
+		           l.text,
+		           q.query,
+		           'StartSel=<b>, StopSel=</b>, MaxWords=20, MinWords=5, MaxFragments=2'
+		       ) AS snippet
+		FROM songs s
+		CROSS JOIN q
+		JOIN artists a ON a.id = s.artist_id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(string_agg(v.text, E'\n\n' ORDER BY v.position), '') AS text
+			FROM verses v
+			WHERE v.song_id = s.id
+		) l
+		WHERE s.search_vector @@ q.query AND s.deleted_at IS NULL
+		ORDER BY rank DESC, s.id
+		LIMIT $3
+		OFFSET $4;
+	`
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, r.currentLanguage(), query.Query, query.Limit, query.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.SearchResult
+
+	for rows.Next() {
+		var music models.SearchResult
+
+		if err := rows.Scan(
+			&music.ID,
+			&music.Group,
+			&music.Song,
+			&music.RelaseDate,
+			&music.Text,
+			&music.Link,
+			&music.EnrichmentStatus,
+			&music.Version,
+			&music.Rank,
+			&music.Snippet,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		result = append(result, music)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *searchPostgres) SetSearchLanguage(ctx context.Context, language string) error {
+	var exists bool
+
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pg_ts_config WHERE cfgname = $1);", language).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check search language: %w", err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: unknown text search configuration %q", models.ErrValidation, language)
+	}
+
+	err = withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// DDL takes no parameters, the name was checked against pg_ts_config above.
+		query := fmt.Sprintf("ALTER TABLE songs ALTER COLUMN search_language SET DEFAULT %s;", pq.QuoteLiteral(language))
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+
+		query = "UPDATE songs SET search_language = $1::REGCONFIG WHERE search_language <> $1::REGCONFIG;"
+		if _, err := tx.ExecContext(ctx, query, language); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set search language: %w", err)
+	}
+
+	r.mu.Lock()
+	r.language = language
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *searchPostgres) currentLanguage() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.language
+}
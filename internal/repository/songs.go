@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"music/internal/models"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// verseSeparator separates verses in the lyrics of models.MusicInfo.
+const verseSeparator = "\n\n"
+
+var (
+	// lineBreaks turns CRLF and CR line ends into newlines.
+	lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+	// verseBreak is the blank line, or several, between two verses.
+	verseBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+)
+
+// splitVerses splits lyrics into verses on blank lines, whether the lines
+// end with newlines or CRLF. Backslashes are text like any other, a literal
+// \n is no line break. Empty verses are dropped.
+func splitVerses(text string) []string {
+	var verses []string
+
+	for _, verse := range verseBreak.Split(lineBreaks.Replace(text), -1) {
+		if verse = strings.TrimSpace(verse); verse != "" {
+			verses = append(verses, verse)
+		}
+	}
+
+	return verses
+}
+
+// joinVerses returns text the way it is read back once stored as verses.
+func joinVerses(text string) string {
+	return strings.Join(splitVerses(text), verseSeparator)
+}
+
+// fillEmpty returns the details of current with the fields it lacks taken
+// from found, so that the enrichment of a song keeps what users set while it
+// was pending.
+func fillEmpty(current, found models.MusicInfo) models.MusicInfo {
+	if current.RelaseDate.IsZero() {
+		current.RelaseDate = found.RelaseDate
+	}
+
+	if current.Text == "" {
+		current.Text = joinVerses(found.Text)
+	}
+
+	if current.Link == "" {
+		current.Link = found.Link
+	}
+
+	return current
+}
+
+// paginateVerses returns size verses starting from the couplet-th, counted
+// from 1, and the number of verses. Out of range pages are empty.
+func paginateVerses(text string, couplet, size int) ([]string, int) {
+	verses := splitVerses(text)
+
+	start := min(max(couplet-1, 0), len(verses))
+	end := min(start+max(size, 0), len(verses))
+
+	return append([]string{}, verses[start:end]...), len(verses)
+}
+
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// upsertArtist returns the ID of the artist with the given name ignoring
+// case, creating it when needed.
+func upsertArtist(ctx context.Context, tx *sql.Tx, name string) (models.Artist, error) {
+	query := `
+		INSERT INTO artists (name) VALUES ($1)
+		ON CONFLICT ((lower(name))) DO UPDATE SET name = artists.name
+		RETURNING id, name;
+	`
+
+	var artist models.Artist
+
+	if err := tx.QueryRowContext(ctx, query, name).Scan(&artist.ID, &artist.Name); err != nil {
+		return artist, fmt.Errorf("failed to upsert artist: %w", err)
+	}
+
+	return artist, nil
+}
+
+// replaceVerses stores text as the lyrics of the song.
+func replaceVerses(ctx context.Context, tx *sql.Tx, songID int, text string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM verses WHERE song_id = $1;", songID); err != nil {
+		return fmt.Errorf("failed to delete verses: %w", err)
+	}
+
+	verses := splitVerses(text)
+	if len(verses) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO verses (song_id, position, text)
+		SELECT $1, v.position, v.text
+		FROM unnest($2::TEXT[]) WITH ORDINALITY AS v (text, position);
+	`
+
+	if _, err := tx.ExecContext(ctx, query, songID, pq.Array(verses)); err != nil {
+		return fmt.Errorf("failed to insert verses: %w", err)
+	}
+
+	return nil
+}
+
+// checkUpdate rejects updates that change nothing or clear the group or the
+// title, which every song has.
+func checkUpdate(updates models.MusicUpdate) error {
+	if updates.Empty() {
+		return models.ErrNoUpdates
+	}
+
+	if updates.Group.Null || updates.Song.Null {
+		return fmt.Errorf("%w: group and song cannot be cleared", models.ErrValidation)
+	}
+
+	return nil
+}
+
+// songKey identifies a song by its group and title ignoring case, as the
+// unique indexes of artists and songs do.
+type songKey struct {
+	group, song string
+}
+
+func newSongKey(group, song string) songKey {
+	return songKey{strings.ToLower(group), strings.ToLower(song)}
+}
+
+// existingSong reports the song of the artist with the given title.
+func existingSong(ctx context.Context, tx *sql.Tx, artistID int, song string) error {
+	var ID int
+
+	err := tx.QueryRowContext(
+		ctx, "SELECT id FROM songs WHERE artist_id = $1 AND lower(song) = lower($2) AND deleted_at IS NULL;", artistID, song,
+	).Scan(&ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch existing song: %w", err)
+	}
+
+	return &models.SongExistsError{ID: ID}
+}
+
+// upsertArtists returns the IDs of the artists with the given names by name,
+// creating the missing ones. Names are matched ignoring case.
+func upsertArtists(ctx context.Context, tx *sql.Tx, names []string) (map[string]int, error) {
+	query := `
+		WITH input AS (
+			SELECT DISTINCT unnest($1::TEXT[]) AS name
+		), upserted AS (
+			INSERT INTO artists (name)
+			SELECT DISTINCT ON (lower(name)) name FROM input ORDER BY lower(name), name
+			ON CONFLICT ((lower(name))) DO UPDATE SET name = artists.name
+			RETURNING id, name
+		)
+		SELECT u.id, i.name
+		FROM input i
+		JOIN upserted u ON lower(u.name) = lower(i.name);
+	`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert artists: %w", wrapPostgresError(err))
+	}
+	defer rows.Close()
+
+	IDs := make(map[string]int, len(names))
+
+	for rows.Next() {
+		var (
+			ID   int
+			name string
+		)
+
+		if err := rows.Scan(&ID, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		IDs[name] = ID
+	}
+
+	return IDs, rows.Err()
+}
+
+// insertVerses stores the lyrics of the new songs of a batch, songs with ID
+// 0 are skipped.
+func insertVerses(ctx context.Context, tx *sql.Tx, musics []models.MusicInfo, IDs []int) error {
+	var songIDs, positions []int64
+	var texts []string
+
+	for i, music := range musics {
+		if IDs[i] == 0 {
+			continue
+		}
+
+		for position, verse := range splitVerses(music.Text) {
+			songIDs = append(songIDs, int64(IDs[i]))
+			positions = append(positions, int64(position+1))
+			texts = append(texts, verse)
+		}
+	}
+
+	if len(texts) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO verses (song_id, position, text)
+		SELECT * FROM unnest($1::INT[], $2::INT[], $3::TEXT[]);
+	`
+
+	if _, err := tx.ExecContext(ctx, query, pq.Array(songIDs), pq.Array(positions), pq.Array(texts)); err != nil {
+		return fmt.Errorf("failed to insert verses: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"music/internal/logging"
+	"music/internal/models"
+	"music/internal/repository"
+	"strings"
+	"time"
+)
+
+type Artist interface {
+	GetArtists(ctx context.Context, limit, offset int) ([]models.Artist, error)
+	GetArtist(ctx context.Context, ID int) (models.Artist, error)
+	GetArtistSongs(ctx context.Context, ID, limit, offset int) ([]models.MusicInfo, error)
+	AddArtist(ctx context.Context, name string) (models.Artist, error)
+	RenameArtist(ctx context.Context, ID int, name string) (models.Artist, error)
+	DeleteArtist(ctx context.Context, ID int) error
+}
+
+type artistService struct {
+	repos   repository.Artist
+	timeout time.Duration
+}
+
+func newArtistService(repos repository.Artist) *artistService {
+	return &artistService{
+		repos:   repos,
+		timeout: 3 * time.Second,
+	}
+}
+
+func (s *artistService) GetArtists(ctx context.Context, limit, offset int) ([]models.Artist, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.GetArtists(c, limit, offset)
+}
+
+func (s *artistService) GetArtist(ctx context.Context, ID int) (models.Artist, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.GetArtist(c, ID)
+}
+
+func (s *artistService) GetArtistSongs(ctx context.Context, ID, limit, offset int) ([]models.MusicInfo, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.GetArtistSongs(c, ID, limit, offset)
+}
+
+func (s *artistService) AddArtist(ctx context.Context, name string) (models.Artist, error) {
+	name, err := validateArtistName(name)
+	if err != nil {
+		return models.Artist{}, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.AddArtist(c, name)
+}
+
+func (s *artistService) RenameArtist(ctx context.Context, ID int, name string) (models.Artist, error) {
+	name, err := validateArtistName(name)
+	if err != nil {
+		return models.Artist{}, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	artist, err := s.repos.RenameArtist(c, ID, name)
+	if err != nil {
+		return artist, err
+	}
+
+	if artist.ID != ID {
+		logging.LoggerFrom(ctx).InfoContext(ctx, "Merged artist songs on rename", slog.Int("from", ID), slog.Int("into", artist.ID))
+	}
+
+	return artist, nil
+}
+
+func (s *artistService) DeleteArtist(ctx context.Context, ID int) error {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.DeleteArtist(c, ID)
+}
+
+func validateArtistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return "", fmt.Errorf("%w: artist name must not be empty", models.ErrValidation)
+	}
+
+	if len(name) > 255 {
+		return "", fmt.Errorf("%w: artist name must be at most 255 bytes", models.ErrValidation)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"music/internal/config"
+	"music/internal/logging"
+	"music/internal/models"
+	"music/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyPrefix     = "mk_"
+	apiKeySize       = 32
+	maxAPIKeyNameLen = 100
+)
+
+// Authenticator checks one kind of credentials and returns whom they belong
+// to. Invalid credentials fail with models.ErrUnauthorized.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (models.Principal, error)
+}
+
+type Auth interface {
+	// Authenticate returns the principal of credentials. Requests without
+	// credentials get the anonymous role. When authentication is disabled
+	// every request is an anonymous admin.
+	Authenticate(ctx context.Context, credentials models.Credentials) (models.Principal, error)
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// AddAPIKey creates a key, the returned APIKey is the only one with Key
+	// set.
+	AddAPIKey(ctx context.Context, name string, role models.Role) (models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, ID int) error
+}
+
+type authService struct {
+	repos     repository.APIKey
+	apiKeys   Authenticator
+	tokens    Authenticator
+	enabled   bool
+	anonymous models.Role
+	timeout   time.Duration
+}
+
+func newAuthService(repos repository.APIKey, cfg config.Config, logger *slog.Logger) *authService {
+	s := &authService{
+		repos:     repos,
+		enabled:   cfg.AuthEnabled,
+		anonymous: models.Role(cfg.AuthAnonymousRole),
+		timeout:   3 * time.Second,
+	}
+
+	s.apiKeys = apiKeyAuthenticator{repos: repos, timeout: s.timeout}
+
+	if s.anonymous != "" && !s.anonymous.Valid() {
+		logger.Error("Invalid anonymous role, requests without credentials are denied",
+			slog.String("role", string(s.anonymous)))
+		s.anonymous = ""
+	}
+
+	// When the JWKS fails to load, HS256 tokens are still checked and RS256
+	// ones are rejected.
+	tokens, err := newTokenVerifier(cfg)
+	if err != nil {
+		logger.Error("Failed to set up token verification", slog.String("error", err.Error()))
+	}
+
+	if tokens != nil {
+		s.tokens = tokens
+	}
+
+	return s
+}
+
+func (s *authService) Authenticate(ctx context.Context, credentials models.Credentials) (models.Principal, error) {
+	switch {
+	case !s.enabled:
+		return models.Principal{Role: models.RoleAdmin}, nil
+	case credentials.APIKey != "":
+		return s.apiKeys.Authenticate(ctx, credentials.APIKey)
+	case credentials.Token != "":
+		if s.tokens == nil {
+			return models.Principal{}, fmt.Errorf("%w: bearer tokens are not accepted", models.ErrUnauthorized)
+		}
+
+		return s.tokens.Authenticate(ctx, credentials.Token)
+	default:
+		return models.Principal{Role: s.anonymous}, nil
+	}
+}
+
+func (s *authService) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.GetAPIKeys(c)
+}
+
+func (s *authService) AddAPIKey(ctx context.Context, name string, role models.Role) (models.APIKey, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" || len(name) > maxAPIKeyNameLen {
+		return models.APIKey{}, fmt.Errorf("%w: API key name must be 1 to %d bytes", models.ErrValidation, maxAPIKeyNameLen)
+	}
+
+	if !role.Valid() {
+		return models.APIKey{}, fmt.Errorf("%w: role must be read-only, editor or admin", models.ErrValidation)
+	}
+
+	secret := make([]byte, apiKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return models.APIKey{}, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	raw := apiKeyPrefix + hex.EncodeToString(secret)
+
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	key, err := s.repos.AddAPIKey(c, models.APIKey{Name: name, Role: role}, hashAPIKey(raw))
+	if err != nil {
+		return key, err
+	}
+
+	key.Key = raw
+
+	logging.LoggerFrom(ctx).InfoContext(ctx, "Created API key", slog.Int("id", key.ID), slog.String("name", key.Name),
+		slog.String("role", string(key.Role)))
+
+	return key, nil
+}
+
+func (s *authService) RevokeAPIKey(ctx context.Context, ID int) error {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if err := s.repos.RevokeAPIKey(c, ID); err != nil {
+		return err
+	}
+
+	logging.LoggerFrom(ctx).InfoContext(ctx, "Revoked API key", slog.Int("id", ID))
+
+	return nil
+}
+
+// apiKeyAuthenticator looks the hash of a key up among the keys in use.
+type apiKeyAuthenticator struct {
+	repos   repository.APIKey
+	timeout time.Duration
+}
+
+func (a apiKeyAuthenticator) Authenticate(ctx context.Context, key string) (models.Principal, error) {
+	c, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	stored, err := a.repos.GetAPIKeyByHash(c, hashAPIKey(key))
+	if errors.Is(err, models.ErrNotFound) {
+		return models.Principal{}, fmt.Errorf("%w: invalid API key", models.ErrUnauthorized)
+	}
+	if err != nil {
+		return models.Principal{}, err
+	}
+
+	return models.Principal{Subject: stored.Name, Role: stored.Role, Method: models.AuthAPIKey}, nil
+}
+
+// hashAPIKey returns the SHA-256 of a key. Keys are long and random, so a
+// fast hash is enough and lets them be looked up by it.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+
+	cfg := config.Config{AuthEnabled: true, AuthAnonymousRole: string(models.RoleReadOnly)}
+	auth := newAuthService(repos.APIKey, cfg, discardLogger())
+
+	editor, err := auth.AddAPIKey(ctx, "ci", models.RoleEditor)
+	if err != nil {
+		t.Fatalf("AddAPIKey: %v", err)
+	}
+
+	revoked, err := auth.AddAPIKey(ctx, "old", models.RoleAdmin)
+	if err != nil {
+		t.Fatalf("AddAPIKey: %v", err)
+	}
+	if err := auth.RevokeAPIKey(ctx, revoked.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+
+	withTokens := newAuthService(repos.APIKey, config.Config{
+		AuthEnabled:      true,
+		AuthJWTSecret:    testSecret,
+		AuthJWTRoleClaim: "role",
+	}, discardLogger())
+
+	token := hs256(t, map[string]any{"alg": "HS256"},
+		map[string]any{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix(), "role": "admin"}, testSecret)
+
+	tests := []struct {
+		name        string
+		auth        *authService
+		credentials models.Credentials
+		want        models.Principal
+		wantErr     bool
+	}{
+		{"API key", auth, models.Credentials{APIKey: editor.Key},
+			models.Principal{Subject: "ci", Role: models.RoleEditor, Method: models.AuthAPIKey}, false},
+		{"revoked API key", auth, models.Credentials{APIKey: revoked.Key}, models.Principal{}, true},
+		{"unknown API key", auth, models.Credentials{APIKey: "mk_unknown"}, models.Principal{}, true},
+		{"no credentials", auth, models.Credentials{}, models.Principal{Role: models.RoleReadOnly}, false},
+		{"token without a verifier", auth, models.Credentials{Token: token}, models.Principal{}, true},
+		{"token", withTokens, models.Credentials{Token: token},
+			models.Principal{Subject: "alice", Role: models.RoleAdmin, Method: models.AuthToken}, false},
+		{"no credentials without an anonymous role", withTokens, models.Credentials{}, models.Principal{}, false},
+		{"disabled", newAuthService(repos.APIKey, config.Config{}, discardLogger()),
+			models.Credentials{APIKey: "mk_unknown"}, models.Principal{Role: models.RoleAdmin}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tt.auth.Authenticate(ctx, tt.credentials)
+
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrUnauthorized) {
+					t.Errorf("Authenticate = %+v, %v, want %v", principal, err, models.ErrUnauthorized)
+				}
+				return
+			}
+
+			if err != nil || principal != tt.want {
+				t.Errorf("Authenticate = %+v, %v, want %+v", principal, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"music/internal/models"
+	"strings"
+)
+
+// cursorToken is the content of the opaque cursor handed to clients. It
+// remembers the sort so that a cursor is not reused with another one.
+type cursorToken struct {
+	Sort string `json:"s"`
+	models.Cursor
+}
+
+func encodeCursor(cursor models.Cursor, keys []models.SortField) string {
+	data, _ := json.Marshal(cursorToken{Sort: sortSignature(keys), Cursor: cursor})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string, keys []models.SortField) (models.Cursor, error) {
+	var decoded cursorToken
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return models.Cursor{}, fmt.Errorf("%w: invalid cursor", models.ErrValidation)
+	}
+
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID < 1 {
+		return models.Cursor{}, fmt.Errorf("%w: invalid cursor", models.ErrValidation)
+	}
+
+	if decoded.Sort != sortSignature(keys) || len(decoded.Values) != len(keys)-1 {
+		return models.Cursor{}, fmt.Errorf("%w: cursor does not match the sort", models.ErrValidation)
+	}
+
+	for i, key := range keys[:len(keys)-1] {
+		if key.Field != models.SortReleaseDate {
+			continue
+		}
+
+		if _, err := models.ParseDate(decoded.Values[i]); err != nil {
+			return models.Cursor{}, fmt.Errorf("%w: invalid cursor", models.ErrValidation)
+		}
+	}
+
+	return decoded.Cursor, nil
+}
+
+func sortSignature(keys []models.SortField) string {
+	fields := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if key.Desc {
+			fields = append(fields, "-"+key.Field)
+		} else {
+			fields = append(fields, key.Field)
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	byDate := []models.SortField{{Field: models.SortReleaseDate, Desc: true}, {Field: models.SortID}}
+	bySong := []models.SortField{{Field: models.SortSong}, {Field: models.SortID}}
+
+	cursor := models.Cursor{Values: []string{"2006-07-16"}, ID: 7}
+	token := encodeCursor(cursor, byDate)
+
+	got, err := decodeCursor(token, byDate)
+	if err != nil || got.ID != cursor.ID || !slices.Equal(got.Values, cursor.Values) {
+		t.Fatalf("decodeCursor(encodeCursor(%+v)) = %+v, %v", cursor, got, err)
+	}
+
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		keys  []models.SortField
+	}{
+		{"not base64", token + "!", byDate},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"-release_date,id","v":["2006-07-16"],"id":7}`)), byDate},
+		{"truncated", token[:len(token)-4], byDate},
+		{"not JSON", encode("cursor"), byDate},
+		{"no ID", encode(`{"s":"-release_date,id","v":["2006-07-16"]}`), byDate},
+		{"invalid date", encode(`{"s":"-release_date,id","v":["31.02.2020"],"id":7}`), byDate},
+		{"missing value", encode(`{"s":"-release_date,id","id":7}`), byDate},
+		{"other sort", token, bySong},
+		{"other direction", token, []models.SortField{{Field: models.SortReleaseDate}, {Field: models.SortID}}},
+		{"default sort", token, models.MusicFilter{}.SortKeys()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := decodeCursor(tt.token, tt.keys); !errors.Is(err, models.ErrValidation) {
+				t.Errorf("decodeCursor = %+v, %v, want %v", got, err, models.ErrValidation)
+			}
+		})
+	}
+}
+
+func TestGetMusicsPage(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := NewService(repos, discardScheduler{}, config.Config{}, discardLogger())
+
+	for i, song := range []string{"Uprising", "Resistance", "Undisclosed Desires", "Exogenesis", "Unnatural Selection"} {
+		_, err := repos.Music.AddMusic(ctx, models.MusicInfo{
+			Group: "Muse", Song: song, RelaseDate: models.NewDate(2009, time.September, 14-i%2),
+		})
+		if err != nil {
+			t.Fatalf("AddMusic: %v", err)
+		}
+	}
+
+	filter := models.MusicFilter{
+		Sort:  []models.SortField{{Field: models.SortReleaseDate, Desc: true}},
+		Limit: 2,
+	}
+
+	var (
+		songs  []string
+		cursor string
+		pages  int
+	)
+
+	for {
+		page, err := services.Music.GetMusicsPage(ctx, filter, cursor)
+		if err != nil {
+			t.Fatalf("GetMusicsPage(%q): %v", cursor, err)
+		}
+
+		pages++
+		for _, music := range page.Items {
+			songs = append(songs, music.Song)
+		}
+
+		if page.HasMore != (page.NextCursor != "") {
+			t.Fatalf("page %d has more: %t with next cursor %q", pages, page.HasMore, page.NextCursor)
+		}
+
+		if !page.HasMore {
+			break
+		}
+
+		cursor = page.NextCursor
+	}
+
+	// Songs of the same date come by ID.
+	want := []string{"Uprising", "Undisclosed Desires", "Unnatural Selection", "Resistance", "Exogenesis"}
+	if pages != 3 || !slices.Equal(songs, want) {
+		t.Errorf("got %v in %d pages, want %v in 3", songs, pages, want)
+	}
+
+	// The cursor of the last full page leads to the last page only.
+	last, err := services.Music.GetMusicsPage(ctx, filter, cursor)
+	if err != nil || last.HasMore || len(last.Items) != 1 || last.Items[0].Song != "Exogenesis" {
+		t.Errorf("last page = %+v, %v, want Exogenesis alone", last, err)
+	}
+
+	if _, err := services.Music.GetMusicsPage(ctx, filter, strings.ToUpper(cursor)); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("GetMusicsPage with a tampered cursor: got %v, want %v", err, models.ErrValidation)
+	}
+
+	filter.Sort = []models.SortField{{Field: models.SortSong}}
+	if _, err := services.Music.GetMusicsPage(ctx, filter, cursor); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("GetMusicsPage with a cursor of another sort: got %v, want %v", err, models.ErrValidation)
+	}
+}
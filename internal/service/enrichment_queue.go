@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/tracing"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	enrichmentJobTimeout = 30 * time.Second
+	// enrichmentLease is how long a polled song is left to this queue before
+	// another poller, possibly of another replica, may take it. It covers
+	// the wait in the queue and the job.
+	enrichmentLease     = 2 * enrichmentJobTimeout
+	defaultPollInterval = 10 * time.Second
+	// enrichmentActor is the actor of the revisions made by the queue.
+	enrichmentActor = "enrichment"
+)
+
+// EnrichmentQueue fills in release dates, lyrics and links of pending songs
+// in the background. Songs are picked up right after they are added and by a
+// periodic poll of the repository, which also resumes work left over by a
+// previous run and retries failed attempts once their backoff has passed.
+type EnrichmentQueue struct {
+	repos    repository.Enrichment
+	songInfo SongInfoProvider
+	logger   *slog.Logger
+	tracer   trace.Tracer
+
+	workers      int
+	maxAttempts  int
+	pollInterval time.Duration
+	retryBase    time.Duration
+	retryMax     time.Duration
+
+	tasks    chan queuedTask
+	mu       sync.Mutex
+	inFlight map[int]struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// queuedTask is a task with the span that queued it, if any. The enrichment
+// runs in a trace of its own linked to it, as it outlives the request.
+type queuedTask struct {
+	task models.EnrichmentTask
+	link trace.Link
+}
+
+func NewEnrichmentQueue(
+	repos repository.Enrichment, songInfo SongInfoProvider, cfg config.Config, logger *slog.Logger,
+) *EnrichmentQueue {
+	ctx, cancel := context.WithCancel(models.WithAudit(context.Background(), models.Audit{Actor: enrichmentActor}))
+	workers := max(cfg.EnrichmentWorkers, 1)
+
+	pollInterval := cfg.EnrichmentPollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	return &EnrichmentQueue{
+		repos:        repos,
+		songInfo:     songInfo,
+		logger:       logger,
+		workers:      workers,
+		maxAttempts:  max(cfg.EnrichmentMaxAttempts, 1),
+		pollInterval: pollInterval,
+		retryBase:    cfg.EnrichmentRetryBase,
+		retryMax:     cfg.EnrichmentRetryMax,
+		tasks:        make(chan queuedTask, 4*workers),
+		inFlight:     make(map[int]struct{}),
+		tracer:       otel.Tracer(tracerName),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start runs the workers and the poller until Stop is called.
+func (q *EnrichmentQueue) Start() {
+	for range q.workers {
+		q.wg.Add(1)
+		go q.work()
+	}
+
+	q.wg.Add(1)
+	go q.poll()
+}
+
+// Stop stops taking new tasks and waits for the running ones to finish or
+// for ctx to expire. Interrupted songs stay pending and are resumed once
+// their lease has passed.
+func (q *EnrichmentQueue) Stop(ctx context.Context) error {
+	q.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Enqueue schedules a song for enrichment without blocking. When the queue
+// is full the song is left to a later poll.
+func (q *EnrichmentQueue) Enqueue(ctx context.Context, task models.EnrichmentTask) {
+	if !q.claim(task.ID) {
+		return
+	}
+
+	select {
+	case q.tasks <- queuedTask{task: task, link: trace.LinkFromContext(ctx)}:
+	default:
+		q.release(task.ID)
+		q.logger.DebugContext(ctx, "Enrichment queue is full, song left for a later poll", slog.Int("id", task.ID))
+	}
+}
+
+func (q *EnrichmentQueue) poll() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.enqueuePending()
+
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *EnrichmentQueue) enqueuePending() {
+	ctx, cancel := context.WithTimeout(q.ctx, enrichmentJobTimeout)
+	defer cancel()
+
+	tasks, err := q.repos.ClaimPendingEnrichments(ctx, cap(q.tasks), enrichmentLease)
+	if err != nil {
+		if q.ctx.Err() == nil {
+			q.logger.Error("Failed to claim pending enrichments", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	for _, task := range tasks {
+		q.Enqueue(ctx, task)
+	}
+}
+
+func (q *EnrichmentQueue) work() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case queued := <-q.tasks:
+			q.enrich(queued)
+			q.release(queued.task.ID)
+		}
+	}
+}
+
+func (q *EnrichmentQueue) enrich(queued queuedTask) {
+	task := queued.task
+
+	ctx, cancel := context.WithTimeout(q.ctx, enrichmentJobTimeout)
+	defer cancel()
+
+	ctx, span := q.tracer.Start(ctx, "EnrichmentQueue.enrich",
+		trace.WithNewRoot(),
+		trace.WithLinks(queued.link),
+		trace.WithAttributes(attribute.Int("music.song_id", task.ID), attribute.Int("music.attempt", task.Attempts+1)),
+	)
+
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	logger := q.logger.With(slog.Int("id", task.ID), slog.Int("attempt", task.Attempts+1))
+
+	info, err := q.songInfo.GetSongInfo(ctx, task.Group, task.Song)
+	if err != nil {
+		if q.ctx.Err() != nil {
+			// Shutting down, the song stays pending for the next run.
+			return
+		}
+
+		var retryAt time.Time
+		if task.Attempts+1 < q.maxAttempts {
+			retryAt = time.Now().Add(q.backoff(task.Attempts))
+		}
+
+		logger.WarnContext(ctx, "Failed to enrich song", slog.String("error", err.Error()))
+
+		if err := q.repos.FailEnrichment(ctx, task.ID, err.Error(), retryAt); err != nil {
+			logger.ErrorContext(ctx, "Failed to record enrichment failure", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	// A date the API got wrong does not spoil the rest of the info.
+	releaseDate, dateErr := models.ParseDate(info.RelaseDate)
+	if dateErr != nil {
+		logger.WarnContext(ctx, "Ignoring invalid release date", slog.String("error", dateErr.Error()))
+	}
+
+	err = q.repos.CompleteEnrichment(ctx, task.ID, models.MusicInfo{
+		RelaseDate: releaseDate,
+		Text:       info.Text,
+		Link:       info.Link,
+	})
+	if errors.Is(err, models.ErrNotFound) {
+		// The song was deleted meanwhile.
+		err = nil
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to store song info", slog.String("error", err.Error()))
+		return
+	}
+
+	logger.DebugContext(ctx, "Song enriched")
+}
+
+// backoff returns a delay in [d/2, d) with d = min(retryBase*2^attempts, retryMax).
+func (q *EnrichmentQueue) backoff(attempts int) time.Duration {
+	d := q.retryBase << attempts
+	if d <= 0 || d > q.retryMax {
+		d = q.retryMax
+	}
+
+	if d <= 1 {
+		return d
+	}
+
+	return d/2 + rand.N(d/2)
+}
+
+func (q *EnrichmentQueue) claim(ID int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.inFlight[ID]; ok {
+		return false
+	}
+
+	q.inFlight[ID] = struct{}{}
+
+	return true
+}
+
+func (q *EnrichmentQueue) release(ID int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.inFlight, ID)
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"music/internal/config"
+	"music/internal/enrichment"
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/tracing/tracingtest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+// songInfo answers every song with info.
+type songInfo struct {
+	info enrichment.Info
+}
+
+func (s songInfo) GetSongInfo(ctx context.Context, group, song string) (enrichment.Info, error) {
+	return s.info, nil
+}
+
+func TestEnrichmentLinksQueuingRequest(t *testing.T) {
+	exporter := tracingtest.Record(t)
+	repos := repository.NewMemoryRepository()
+
+	stored, err := repos.Music.AddMusic(context.Background(), models.MusicInfo{
+		Group: "Muse", Song: "Uprising", EnrichmentStatus: models.EnrichmentPending,
+	})
+	if err != nil {
+		t.Fatalf("AddMusic: %v", err)
+	}
+
+	queue := NewEnrichmentQueue(repos.Enrichment, songInfo{enrichment.Info{Link: "https://example.com"}},
+		config.Config{}, discardLogger())
+
+	ctx, request := otel.Tracer("test").Start(context.Background(), "request")
+	queue.Enqueue(ctx, models.EnrichmentTask{ID: stored.ID, Group: stored.Group, Song: stored.Song})
+	request.End()
+
+	// The worker is run by hand, after the request has ended.
+	queue.enrich(<-queue.tasks)
+
+	status, err := repos.Enrichment.GetEnrichmentStatus(context.Background(), stored.ID)
+	if err != nil || status.Status != models.EnrichmentEnriched {
+		t.Fatalf("GetEnrichmentStatus = %+v, %v, want the song enriched", status, err)
+	}
+
+	span := findSpan(t, exporter.GetSpans(), "EnrichmentQueue.enrich")
+
+	if span.Parent.IsValid() || span.SpanContext.TraceID() == request.SpanContext().TraceID() {
+		t.Errorf("enrich span is in trace %s with parent %s, want the root of a new trace",
+			span.SpanContext.TraceID(), span.Parent.SpanID())
+	}
+
+	if len(span.Links) != 1 || !span.Links[0].SpanContext.Equal(request.SpanContext()) {
+		t.Errorf("enrich span links %+v, want the request span %s", span.Links, request.SpanContext().SpanID())
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"music/internal/models"
+	"music/internal/repository"
+	"time"
+)
+
+// Idempotency keeps the responses of requests sent with an Idempotency-Key
+// header so that a retry gets the original response. Keys belong to the
+// principal in ctx, so that clients cannot see each other's responses.
+type Idempotency interface {
+	ReserveIdempotencyKey(ctx context.Context, key, fingerprint string) (models.IdempotencyRecord, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, key string, record models.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+}
+
+type idempotencyService struct {
+	repos   repository.Idempotency
+	logger  *slog.Logger
+	ttl     time.Duration
+	timeout time.Duration
+}
+
+func newIdempotencyService(repos repository.Idempotency, ttl time.Duration, logger *slog.Logger) *idempotencyService {
+	return &idempotencyService{
+		repos:   repos,
+		logger:  logger,
+		ttl:     ttl,
+		timeout: 3 * time.Second,
+	}
+}
+
+func (s *idempotencyService) ReserveIdempotencyKey(
+	ctx context.Context, key, fingerprint string,
+) (models.IdempotencyRecord, bool, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.ReserveIdempotencyKey(c, scopedKey(ctx, key), fingerprint, s.ttl)
+}
+
+func (s *idempotencyService) CompleteIdempotencyKey(
+	ctx context.Context, key string, record models.IdempotencyRecord,
+) error {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.CompleteIdempotencyKey(c, scopedKey(ctx, key), record)
+}
+
+func (s *idempotencyService) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.ReleaseIdempotencyKey(c, scopedKey(ctx, key))
+}
+
+// scopedKey is the key as stored: a hash of the key and the subject that
+// sent it, which also keeps it within the 255 bytes of a stored key.
+func scopedKey(ctx context.Context, key string) string {
+	sum := sha256.Sum256([]byte(models.PrincipalFrom(ctx).Subject + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
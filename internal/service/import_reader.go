@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"music/internal/models"
+	"strings"
+)
+
+// importReader streams the rows of a catalog. A malformed row is returned
+// as a *rowError and the reader moves on to the next one.
+type importReader interface {
+	next() (line int, row models.ImportRow, err error)
+}
+
+type rowError struct {
+	line int
+	err  error
+}
+
+func (e *rowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+func newImportReader(r io.Reader, format models.ImportFormat) (importReader, error) {
+	switch format {
+	case models.ImportNDJSON:
+		return &ndjsonReader{r: bufio.NewReader(r)}, nil
+	case models.ImportCSV:
+		return newCSVReader(r)
+	default:
+		return nil, fmt.Errorf("%w: unknown import format %q", models.ErrValidation, format)
+	}
+}
+
+type ndjsonReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (r *ndjsonReader) next() (int, models.ImportRow, error) {
+	for {
+		// ReadBytes, unlike a Scanner, has no limit on the length of lyrics.
+		data, err := r.r.ReadBytes('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || len(data) == 0) {
+			return r.line, models.ImportRow{}, err
+		}
+
+		r.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		var row models.ImportRow
+
+		if err := json.Unmarshal(data, &row); err != nil {
+			return r.line, row, &rowError{line: r.line, err: err}
+		}
+
+		return r.line, row, nil
+	}
+}
+
+// csvColumns are the columns a CSV catalog may have, group and song are
+// required. The id and enrichment_status columns of an export are ignored.
+var csvColumns = map[string]func(row *models.ImportRow) *string{
+	"id":                func(row *models.ImportRow) *string { return new(string) },
+	"enrichment_status": func(row *models.ImportRow) *string { return new(string) },
+	"group":             func(row *models.ImportRow) *string { return &row.Group },
+	"song":              func(row *models.ImportRow) *string { return &row.Song },
+	"release_date":      func(row *models.ImportRow) *string { return &row.RelaseDate },
+	"text":              func(row *models.ImportRow) *string { return &row.Text },
+	"link":              func(row *models.ImportRow) *string { return &row.Link },
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	fields []func(row *models.ImportRow) *string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read CSV header: %v", models.ErrValidation, err)
+	}
+
+	fields := make([]func(row *models.ImportRow) *string, 0, len(header))
+	seen := make(map[string]bool, len(header))
+
+	for _, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+
+		field, ok := csvColumns[name]
+		if !ok || seen[name] {
+			return nil, fmt.Errorf("%w: unknown or repeated CSV column %q", models.ErrValidation, name)
+		}
+
+		seen[name] = true
+		fields = append(fields, field)
+	}
+
+	if !seen["group"] || !seen["song"] {
+		return nil, fmt.Errorf("%w: CSV header must have group and song columns", models.ErrValidation)
+	}
+
+	return &csvReader{r: reader, fields: fields}, nil
+}
+
+func (r *csvReader) next() (int, models.ImportRow, error) {
+	var row models.ImportRow
+
+	record, err := r.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.StartLine, row, &rowError{line: parseErr.StartLine, err: parseErr.Err}
+		}
+
+		return 0, row, err
+	}
+
+	line, _ := r.r.FieldPos(0)
+
+	if len(record) != len(r.fields) {
+		return line, row, &rowError{
+			line: line,
+			err:  fmt.Errorf("expected %d fields, got %d", len(r.fields), len(record)),
+		}
+	}
+
+	for i, value := range record {
+		*r.fields[i](&row) = value
+	}
+
+	return line, row, nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readRow is a row read from an import, Err is the message of a row error.
+type readRow struct {
+	Line int
+	Row  models.ImportRow
+	Err  string
+}
+
+func readAll(t *testing.T, r importReader) []readRow {
+	t.Helper()
+
+	var rows []readRow
+
+	for {
+		line, row, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+
+		var rowErr *rowError
+
+		switch {
+		case errors.As(err, &rowErr):
+			rows = append(rows, readRow{Line: line, Err: rowErr.err.Error()})
+		case err != nil:
+			t.Fatalf("next: %v", err)
+		default:
+			rows = append(rows, readRow{Line: line, Row: row})
+		}
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+	input := `{"group": "Muse", "song": "Uprising"}
+
+{"group": "Muse", "song": "Resistance", "release_date": "14.09.2009"}
+{"group": "Muse", "song":
+  {"group": "Muse", "song": "Exogenesis", "text": "verse"}
+{"group": "Muse", "song": "Undisclosed Desires"}`
+
+	r, err := newImportReader(strings.NewReader(input), models.ImportNDJSON)
+	if err != nil {
+		t.Fatalf("newImportReader: %v", err)
+	}
+
+	rows := readAll(t, r)
+
+	want := []readRow{
+		{Line: 1, Row: models.ImportRow{Group: "Muse", Song: "Uprising"}},
+		{Line: 3, Row: models.ImportRow{Group: "Muse", Song: "Resistance", RelaseDate: "14.09.2009"}},
+		{Line: 4, Err: "unexpected end of JSON input"},
+		{Line: 5, Row: models.ImportRow{Group: "Muse", Song: "Exogenesis", Text: "verse"}},
+		{Line: 6, Row: models.ImportRow{Group: "Muse", Song: "Undisclosed Desires"}},
+	}
+
+	if !slices.Equal(rows, want) {
+		t.Errorf("read %+v, want %+v", rows, want)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []readRow
+	}{
+		{
+			name:  "required columns",
+			input: "group,song\nMuse,Uprising\n",
+			want:  []readRow{{Line: 2, Row: models.ImportRow{Group: "Muse", Song: "Uprising"}}},
+		},
+		{
+			name:  "export header",
+			input: "\ufeffID, Group ,song,release_date,text,link,enrichment_status\n7,Muse,Uprising,2009-09-14,verse,https://example.com,enriched\n",
+			want: []readRow{{Line: 2, Row: models.ImportRow{
+				Group: "Muse", Song: "Uprising", RelaseDate: "2009-09-14", Text: "verse", Link: "https://example.com",
+			}}},
+		},
+		{
+			name:  "quoted lyrics",
+			input: "song,group,text\n\"Uprising\",Muse,\"Paranoia is in bloom,\nthe PR transmissions will resume\"\nResistance,Muse,\n",
+			want: []readRow{
+				{Line: 2, Row: models.ImportRow{
+					Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom,\nthe PR transmissions will resume",
+				}},
+				{Line: 4, Row: models.ImportRow{Group: "Muse", Song: "Resistance"}},
+			},
+		},
+		{
+			name:  "malformed rows",
+			input: "group,song\nMuse\nMuse,\"Uprising\nMuse,Resistance\n",
+			want: []readRow{
+				{Line: 2, Err: "expected 2 fields, got 1"},
+				{Line: 3, Err: "extraneous or missing \" in quoted-field"},
+			},
+		},
+		{
+			name:  "no rows",
+			input: "group,song\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newImportReader(strings.NewReader(tt.input), models.ImportCSV)
+			if err != nil {
+				t.Fatalf("newImportReader: %v", err)
+			}
+
+			if rows := readAll(t, r); !slices.Equal(rows, tt.want) {
+				t.Errorf("read %+v, want %+v", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVReaderHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"no song", "group,text\nMuse,verse\n"},
+		{"no group", "song\nUprising\n"},
+		{"unknown column", "group,song,album\nMuse,Uprising,The Resistance\n"},
+		{"repeated column", "group,song,Song\nMuse,Uprising,Resistance\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newImportReader(strings.NewReader(tt.input), models.ImportCSV); !errors.Is(err, models.ErrValidation) {
+				t.Errorf("newImportReader(%q) = %v, want %v", tt.input, err, models.ErrValidation)
+			}
+		})
+	}
+
+	if _, err := newImportReader(strings.NewReader(""), "xml"); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("newImportReader with an unknown format = %v, want %v", err, models.ErrValidation)
+	}
+}
+
+func TestImportMusics(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := NewService(repos, discardScheduler{}, config.Config{}, discardLogger())
+
+	input := "group,song,release_date\n" +
+		"Muse,Uprising,14.09.2009\n" +
+		"Muse,\n" +
+		"Muse,Resistance,31.02.2009\n" +
+		"Muse\n" +
+		"muse,uprising,\n" +
+		"Muse,Exogenesis,\n"
+
+	report, err := services.Import.ImportMusics(ctx, strings.NewReader(input), models.ImportCSV)
+	if err != nil {
+		t.Fatalf("ImportMusics: %v", err)
+	}
+
+	if report.Created != 2 || report.Duplicates != 1 || report.Failed != 3 {
+		t.Errorf("report = %d created, %d duplicates, %d failed, want 2, 1, 3",
+			report.Created, report.Duplicates, report.Failed)
+	}
+
+	want := []struct {
+		line   int
+		status models.ImportStatus
+	}{
+		{2, models.ImportCreated},
+		{3, models.ImportFailed},
+		{4, models.ImportFailed},
+		{5, models.ImportFailed},
+		{6, models.ImportDuplicate},
+		{7, models.ImportCreated},
+	}
+
+	if len(report.Rows) != len(want) {
+		t.Fatalf("report rows = %+v, want %d", report.Rows, len(want))
+	}
+
+	for i, row := range report.Rows {
+		if row.Line != want[i].line || row.Status != want[i].status {
+			t.Errorf("row %d = %+v, want line %d %s", i, row, want[i].line, want[i].status)
+		}
+
+		if (row.Status == models.ImportFailed) != (row.Error != "") {
+			t.Errorf("row %d = %+v, want an error only for a failed row", i, row)
+		}
+	}
+
+	if _, err := services.Import.ImportMusics(ctx, strings.NewReader("song\nUprising\n"), models.ImportCSV); !errors.Is(err, models.ErrValidation) {
+		t.Errorf("ImportMusics without a group column = %v, want %v", err, models.ErrValidation)
+	}
+}
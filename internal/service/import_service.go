@@ -0,0 +1,201 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"music/internal/logging"
+	"music/internal/models"
+	"music/internal/repository"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	importBatchSize    = 500
+	importBatchTimeout = 30 * time.Second
+)
+
+type Import interface {
+	// ImportMusics streams a catalog from r and stores its songs in batches.
+	// Rows without details are queued for enrichment. Malformed rows and
+	// duplicates are reported and skipped, they do not stop the import.
+	ImportMusics(ctx context.Context, r io.Reader, format models.ImportFormat) (models.ImportReport, error)
+}
+
+type importService struct {
+	repos     repository.Music
+	scheduler EnrichmentScheduler
+	batchSize int
+	timeout   time.Duration
+}
+
+func newImportService(repos repository.Music, scheduler EnrichmentScheduler) *importService {
+	return &importService{
+		repos:     repos,
+		scheduler: scheduler,
+		batchSize: importBatchSize,
+		timeout:   importBatchTimeout,
+	}
+}
+
+type importLine struct {
+	line  int
+	music models.MusicInfo
+}
+
+func (s *importService) ImportMusics(
+	ctx context.Context, r io.Reader, format models.ImportFormat,
+) (models.ImportReport, error) {
+	report := models.ImportReport{Rows: []models.ImportRowResult{}}
+
+	rows, err := newImportReader(r, format)
+	if err != nil {
+		return report, err
+	}
+
+	batch := make([]importLine, 0, s.batchSize)
+
+	for {
+		line, row, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *rowError
+
+		switch {
+		case errors.As(err, &rowErr):
+			report.Add(models.ImportRowResult{Line: line, Status: models.ImportFailed, Error: rowErr.err.Error()})
+			continue
+		case err != nil:
+			return report, fmt.Errorf("failed to read import: %w", err)
+		}
+
+		music, err := importMusic(row)
+		if err != nil {
+			report.Add(models.ImportRowResult{Line: line, Status: models.ImportFailed, Error: err.Error()})
+			continue
+		}
+
+		batch = append(batch, importLine{line: line, music: music})
+
+		if len(batch) == s.batchSize {
+			if err := s.storeBatch(ctx, batch, &report); err != nil {
+				return report, err
+			}
+
+			batch = batch[:0]
+		}
+	}
+
+	if err := s.storeBatch(ctx, batch, &report); err != nil {
+		return report, err
+	}
+
+	// Malformed rows are reported as they are read, ahead of their batch.
+	slices.SortStableFunc(report.Rows, func(a, b models.ImportRowResult) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+
+	logging.LoggerFrom(ctx).InfoContext(ctx, "Imported songs",
+		slog.Int("created", report.Created),
+		slog.Int("duplicates", report.Duplicates),
+		slog.Int("failed", report.Failed),
+	)
+
+	return report, nil
+}
+
+// storeBatch stores the rows of batch and records their outcome. When the
+// batch cannot be stored its rows are reported as failed, only a canceled
+// ctx stops the import.
+func (s *importService) storeBatch(ctx context.Context, batch []importLine, report *models.ImportReport) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	musics := make([]models.MusicInfo, 0, len(batch))
+
+	for _, l := range batch {
+		musics = append(musics, l.music)
+	}
+
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	IDs, err := s.repos.AddMusics(c, musics)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		logging.LoggerFrom(ctx).ErrorContext(ctx, "Failed to store import batch",
+			slog.Int("from_line", batch[0].line),
+			slog.String("error", err.Error()),
+		)
+
+		for _, l := range batch {
+			report.Add(models.ImportRowResult{Line: l.line, Status: models.ImportFailed, Error: "failed to store the row"})
+		}
+
+		return nil
+	}
+
+	for i, l := range batch {
+		if IDs[i] == 0 {
+			report.Add(models.ImportRowResult{Line: l.line, Status: models.ImportDuplicate})
+			continue
+		}
+
+		report.Add(models.ImportRowResult{Line: l.line, Status: models.ImportCreated, ID: IDs[i]})
+
+		if musics[i].EnrichmentStatus == models.EnrichmentPending {
+			s.scheduler.Enqueue(ctx, models.EnrichmentTask{ID: IDs[i], Group: l.music.Group, Song: l.music.Song})
+		}
+	}
+
+	return nil
+}
+
+// importMusic validates a row and returns the song to store. Rows with
+// details are stored as enriched.
+func importMusic(row models.ImportRow) (models.MusicInfo, error) {
+	row.RelaseDate = strings.TrimSpace(row.RelaseDate)
+
+	music := models.MusicInfo{
+		Song:             strings.TrimSpace(row.Song),
+		Text:             row.Text,
+		Link:             row.Link,
+		EnrichmentStatus: models.EnrichmentPending,
+	}
+
+	group, err := validateArtistName(row.Group)
+	if err != nil {
+		return music, errors.New("group must be 1 to 255 bytes")
+	}
+
+	music.Group = group
+
+	if music.Song == "" || len(music.Song) > 255 {
+		return music, errors.New("song must be 1 to 255 bytes")
+	}
+
+	if len(music.Link) > 255 {
+		return music, errors.New("link must be at most 255 bytes")
+	}
+
+	if music.RelaseDate, err = models.ParseDate(row.RelaseDate); err != nil {
+		return music, errors.New("release_date must be YYYY-MM-DD or DD.MM.YYYY")
+	}
+
+	if row.HasDetails() {
+		music.EnrichmentStatus = models.EnrichmentEnriched
+	}
+
+	return music, nil
+}
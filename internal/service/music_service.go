@@ -2,128 +2,328 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
-	"music/internal/config"
+	"music/internal/enrichment"
+	"music/internal/logging"
 	"music/internal/models"
 	"music/internal/repository"
-	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
-const songBaseUrl = "https://example.com"
+// SongInfoProvider fetches the metadata of a song from an external source.
+type SongInfoProvider interface {
+	GetSongInfo(ctx context.Context, group, song string) (enrichment.Info, error)
+}
+
+// EnrichmentScheduler queues songs for background enrichment. The trace of
+// ctx is linked to the enrichment, ctx is not kept.
+type EnrichmentScheduler interface {
+	Enqueue(ctx context.Context, task models.EnrichmentTask)
+}
 
 type Music interface {
-	GetMusics(ctx context.Context, group, song, releaseDate, text string, limit, offset int) ([]models.MusicInfo, error)
-	GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) ([]string, error)
-	AddMusic(ctx context.Context, music models.Music) error
-	UpdateMusic(ctx context.Context, ID int, updates models.MusicUpdate) error
-	DeleteMusic(ctx context.Context, ID int) error
+	GetMusics(ctx context.Context, filter models.MusicFilter) ([]models.MusicInfo, error)
+	// GetMusicsPage lists songs after the position encoded in cursor, an
+	// empty cursor starts from the beginning.
+	GetMusicsPage(ctx context.Context, filter models.MusicFilter, cursor string) (models.MusicPage, error)
+	// ExportMusics streams every song that matches filter to yield. It is
+	// bounded by ctx only, as large catalogs take longer than a request.
+	ExportMusics(ctx context.Context, filter models.MusicFilter, yield func(models.MusicInfo) error) error
+	GetMusic(ctx context.Context, ID int) (models.MusicInfo, error)
+	// GetSongLyricsByVerses returns a page of the verses of a song. A couplet
+	// past the last verse fails with models.ErrOutOfRange.
+	GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) (models.Lyrics, error)
+	AddMusic(ctx context.Context, music models.Music) (models.MusicInfo, error)
+	GetEnrichmentStatus(ctx context.Context, ID int) (models.EnrichmentStatus, error)
+	// UpdateMusic applies a merge patch to the song. It and DeleteMusic with a
+	// non-zero version only apply to the song at that version, see
+	// repository.Music.
+	UpdateMusic(ctx context.Context, ID int, updates models.MusicUpdate, version int) (models.MusicInfo, error)
+	DeleteMusic(ctx context.Context, ID, version int) error
+	GetDeletedMusics(ctx context.Context, limit, offset int) ([]models.DeletedMusic, error)
+	RestoreMusic(ctx context.Context, ID int) (models.MusicInfo, error)
+	GetMusicHistory(ctx context.Context, ID, limit, offset int) ([]models.Revision, error)
+	// RevertMusic with a non-zero version only applies to the song at that
+	// version, as UpdateMusic.
+	RevertMusic(ctx context.Context, ID, rev, version int) (models.MusicInfo, error)
+	SearchMusics(ctx context.Context, query models.SearchQuery) ([]models.SearchResult, error)
 }
 
 type musicService struct {
-	repos   repository.Music
-	logger  *slog.Logger
-	timeout time.Duration
+	repos       repository.Music
+	enrichments repository.Enrichment
+	search      repository.Search
+	scheduler   EnrichmentScheduler
+	timeout     time.Duration
 }
 
-func newMusicService(repos repository.Music, logger *slog.Logger) *musicService {
+func newMusicService(
+	repos repository.Music,
+	enrichments repository.Enrichment,
+	search repository.Search,
+	scheduler EnrichmentScheduler,
+) *musicService {
 	return &musicService{
-		repos:   repos,
-		logger:  logger,
-		timeout: 3 * time.Second,
+		repos:       repos,
+		enrichments: enrichments,
+		search:      search,
+		scheduler:   scheduler,
+		timeout:     3 * time.Second,
 	}
 }
 
-func (s *musicService) GetMusics(
-	ctx context.Context, group, song, releaseDate, text string, limit, offset int,
-) ([]models.MusicInfo, error) {
+func (s *musicService) GetMusics(ctx context.Context, filter models.MusicFilter) ([]models.MusicInfo, error) {
 	c, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	return s.repos.GetMusics(c, group, song, releaseDate, text, limit, offset)
+	return s.repos.GetMusics(c, filter)
 }
 
-func (s *musicService) GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) ([]string, error) {
+func (s *musicService) GetMusicsPage(
+	ctx context.Context, filter models.MusicFilter, cursor string,
+) (models.MusicPage, error) {
+	page := models.MusicPage{Items: []models.MusicInfo{}}
+
+	keys := filter.SortKeys()
+
+	filter.Offset = 0
+	filter.After = nil
+
+	if cursor != "" {
+		after, err := decodeCursor(cursor, keys)
+		if err != nil {
+			return page, err
+		}
+
+		filter.After = &after
+	}
+
 	c, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	return s.repos.GetSongLyricsByVerses(c, ID, couplet, size)
+	// One extra song tells whether there is a next page.
+	limit := filter.Limit
+	filter.Limit++
+
+	musics, err := s.repos.GetMusics(c, filter)
+	if err != nil {
+		return page, err
+	}
+
+	if len(musics) > limit {
+		musics = musics[:limit]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(models.NewCursor(musics[limit-1], keys), keys)
+	}
+
+	if len(musics) > 0 {
+		page.Items = musics
+	}
+
+	return page, nil
 }
 
-func (s *musicService) AddMusic(ctx context.Context, music models.Music) error {
+func (s *musicService) ExportMusics(
+	ctx context.Context, filter models.MusicFilter, yield func(models.MusicInfo) error,
+) error {
+	return s.repos.ExportMusics(ctx, filter, yield)
+}
+
+func (s *musicService) GetMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
 	c, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	response, err := s.getMusic(music)
+	return s.repos.GetMusic(c, ID)
+}
+
+func (s *musicService) GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) (models.Lyrics, error) {
+	if couplet < 1 || size < 1 {
+		return models.Lyrics{}, fmt.Errorf("%w: couplet and size must be positive", models.ErrValidation)
+	}
+
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	lyrics, err := s.repos.GetSongLyricsByVerses(c, ID, couplet, size)
 	if err != nil {
-		return err
+		return models.Lyrics{}, err
 	}
-	s.logger.DebugContext(ctx, "Got music response")
 
-	return s.repos.AddMusic(c, response)
+	// The first page of a song without lyrics is empty rather than missing.
+	if couplet > max(lyrics.TotalVerses, 1) {
+		return models.Lyrics{}, fmt.Errorf(
+			"%w: couplet %d, the song has %d verses", models.ErrOutOfRange, couplet, lyrics.TotalVerses,
+		)
+	}
+
+	lyrics.HasNext = couplet-1+len(lyrics.Verses) < lyrics.TotalVerses
+
+	return lyrics, nil
 }
 
-func (s *musicService) UpdateMusic(ctx context.Context, ID int, updates models.MusicUpdate) error {
+// AddMusic stores the song right away and leaves its release date, lyrics
+// and link to the enrichment queue. The song is returned as stored, still
+// pending.
+func (s *musicService) AddMusic(ctx context.Context, music models.Music) (models.MusicInfo, error) {
+	music, err := validateMusic(music)
+	if err != nil {
+		return models.MusicInfo{}, err
+	}
+
 	c, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	return s.repos.UpdateMusic(c, ID, updates)
+	stored, err := s.repos.AddMusic(c, models.MusicInfo{
+		Group:            music.Group,
+		Song:             music.Song,
+		EnrichmentStatus: models.EnrichmentPending,
+	})
+	if err != nil {
+		return models.MusicInfo{}, err
+	}
+
+	s.scheduler.Enqueue(ctx, models.EnrichmentTask{ID: stored.ID, Group: music.Group, Song: music.Song})
+	logging.LoggerFrom(ctx).DebugContext(ctx, "Song queued for enrichment", slog.Int("id", stored.ID))
+
+	return stored, nil
 }
 
-func (s *musicService) DeleteMusic(ctx context.Context, ID int) error {
+func (s *musicService) GetEnrichmentStatus(ctx context.Context, ID int) (models.EnrichmentStatus, error) {
 	c, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	return s.repos.DeleteMusic(c, ID)
+	return s.enrichments.GetEnrichmentStatus(c, ID)
 }
 
-func (s *musicService) getMusic(music models.Music) (models.MusicInfo, error) {
-	var result models.MusicInfo
-	songUrl := config.Url + "/info"
+func (s *musicService) UpdateMusic(
+	ctx context.Context, ID int, updates models.MusicUpdate, version int,
+) (models.MusicInfo, error) {
+	updates, err := validateUpdate(updates)
+	if err != nil {
+		return models.MusicInfo{}, err
+	}
 
-	s.logger.Debug("URL to get music", slog.String("url", songUrl))
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
 
-	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodGet, songUrl, nil)
-	if err != nil {
-		return result, err
+	return s.repos.UpdateMusic(c, ID, updates, version)
+}
+
+func (s *musicService) DeleteMusic(ctx context.Context, ID, version int) error {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.DeleteMusic(c, ID, version)
+}
+
+func (s *musicService) GetDeletedMusics(ctx context.Context, limit, offset int) ([]models.DeletedMusic, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.GetDeletedMusics(c, limit, offset)
+}
+
+func (s *musicService) RestoreMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.RestoreMusic(c, ID)
+}
+
+func (s *musicService) GetMusicHistory(ctx context.Context, ID, limit, offset int) ([]models.Revision, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.GetMusicHistory(c, ID, limit, offset)
+}
+
+func (s *musicService) RevertMusic(ctx context.Context, ID, rev, version int) (models.MusicInfo, error) {
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.RevertMusic(c, ID, rev, version)
+}
+
+func (s *musicService) SearchMusics(ctx context.Context, query models.SearchQuery) ([]models.SearchResult, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, fmt.Errorf("%w: search query must not be empty", models.ErrValidation)
 	}
 
-	query := req.URL.Query()
-	query.Add("group", music.Group)
-	query.Add("song", music.Song)
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.search.SearchMusics(c, query)
+}
 
-	req.URL.RawQuery = query.Encode()
+// validateUpdate trims the values an update sets and checks them. The group
+// and the title can be replaced but not cleared, links are absolute http or
+// https URLs. Release dates are checked as they are decoded.
+func validateUpdate(updates models.MusicUpdate) (models.MusicUpdate, error) {
+	if updates.Group.Null || updates.Song.Null {
+		return updates, fmt.Errorf("%w: group and song cannot be cleared", models.ErrValidation)
+	}
 
-	resp, err := client.Do(req)
-	s.logger.Debug("sending request to get music")
-	if err != nil {
-		return result, err
+	if updates.Group.Set {
+		group, err := validateArtistName(updates.Group.Value)
+		if err != nil {
+			return updates, err
+		}
+
+		updates.Group.Value = group
+	}
+
+	if updates.Song.Set {
+		song, err := validateSongTitle(updates.Song.Value)
+		if err != nil {
+			return updates, err
+		}
+
+		updates.Song.Value = song
+	}
+
+	if updates.Link.Replaces() {
+		updates.Link.Value = strings.TrimSpace(updates.Link.Value)
+
+		if !validLink(updates.Link.Value) || len(updates.Link.Value) > 255 {
+			return updates, fmt.Errorf("%w: link must be an http or https URL of at most 255 bytes", models.ErrValidation)
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return result, fmt.Errorf("API returned status: %d", resp.StatusCode)
+	return updates, nil
+}
+
+// validateMusic trims the group and the title of a new song and checks them
+// as validateUpdate does.
+func validateMusic(music models.Music) (models.Music, error) {
+	group, err := validateArtistName(music.Group)
+	if err != nil {
+		return music, err
 	}
-	s.logger.Debug("request status code: ", slog.String("status", resp.Status))
 
-	var apiResponse struct {
-		RelaseDate string `json:"relaseDate"`
-		Text       string `json:"text"`
-		Link       string `json:"link"`
+	song, err := validateSongTitle(music.Song)
+	if err != nil {
+		return music, err
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return result, err
+	return models.Music{Group: group, Song: song}, nil
+}
+
+func validateSongTitle(song string) (string, error) {
+	song = strings.TrimSpace(song)
+
+	if song == "" || len(song) > 255 {
+		return "", fmt.Errorf("%w: song must be 1 to 255 bytes", models.ErrValidation)
 	}
 
-	result.Group = music.Group
-	result.Song = music.Song
-	result.RelaseDate = apiResponse.RelaseDate
-	result.Text = apiResponse.Text
-	result.Link = apiResponse.Link
+	return song, nil
+}
+
+func validLink(link string) bool {
+	u, err := url.Parse(link)
 
-	return result, nil
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
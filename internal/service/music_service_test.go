@@ -0,0 +1,46 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"music/internal/models"
+	"testing"
+)
+
+func TestValidateUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		want    models.MusicUpdate
+		wantErr bool
+	}{
+		{"clear text", `{"text": null}`, models.MusicUpdate{Text: models.Clear[string]()}, false},
+		{"empty", `{}`, models.MusicUpdate{}, false},
+		{"trimmed group", `{"group": " Muse "}`, models.MusicUpdate{Group: models.Set("Muse")}, false},
+		{"clear group", `{"group": null}`, models.MusicUpdate{}, true},
+		{"clear song", `{"song": null}`, models.MusicUpdate{}, true},
+		{"blank song", `{"song": "  "}`, models.MusicUpdate{}, true},
+		{"relative link", `{"link": "/songs/1"}`, models.MusicUpdate{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updates models.MusicUpdate
+			if err := json.Unmarshal([]byte(tt.patch), &updates); err != nil {
+				t.Fatalf("Unmarshal(%s): %v", tt.patch, err)
+			}
+
+			got, err := validateUpdate(updates)
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrValidation) {
+					t.Errorf("validateUpdate(%s) = %+v, %v, want %v", tt.patch, got, err, models.ErrValidation)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("validateUpdate(%s) = %+v, %v, want %+v", tt.patch, got, err, tt.want)
+			}
+		})
+	}
+}
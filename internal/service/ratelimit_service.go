@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"time"
+)
+
+// RateLimit limits how often a client may call each class of routes.
+type RateLimit interface {
+	// TakeRateLimitToken takes a token from the bucket of client for class.
+	// Classes without a limit allow every request, with a zero Limit.
+	TakeRateLimitToken(ctx context.Context, class, client string) (models.RateLimitResult, error)
+}
+
+type rateLimitService struct {
+	repos   repository.RateLimit
+	limits  map[string]models.RateLimit
+	logger  *slog.Logger
+	timeout time.Duration
+	now     func() time.Time
+}
+
+func newRateLimitService(repos repository.RateLimit, cfg config.Config, logger *slog.Logger) *rateLimitService {
+	return &rateLimitService{
+		repos: repos,
+		limits: map[string]models.RateLimit{
+			models.RateLimitRead:  {Requests: cfg.RateLimitRead, Per: cfg.RateLimitPeriod},
+			models.RateLimitWrite: {Requests: cfg.RateLimitWrite, Per: cfg.RateLimitPeriod},
+			models.RateLimitAuth:  {Requests: cfg.RateLimitAuth, Per: cfg.RateLimitPeriod},
+		},
+		logger:  logger,
+		timeout: time.Second,
+		now:     time.Now,
+	}
+}
+
+func (s *rateLimitService) TakeRateLimitToken(
+	ctx context.Context, class, client string,
+) (models.RateLimitResult, error) {
+	limit := s.limits[class]
+	if !limit.Enabled() {
+		return models.RateLimitResult{Allowed: true}, nil
+	}
+
+	c, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.repos.TakeRateLimitToken(c, class+":"+client, limit, s.now())
+}
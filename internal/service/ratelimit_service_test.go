@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	s := newRateLimitService(repository.NewRateLimitMemory(), config.Config{
+		RateLimitRead: 2, RateLimitWrite: 1, RateLimitPeriod: 10 * time.Second,
+	}, discardLogger())
+	s.now = func() time.Time { return now }
+
+	take := func(class, client string) models.RateLimitResult {
+		t.Helper()
+
+		result, err := s.TakeRateLimitToken(ctx, class, client)
+		if err != nil {
+			t.Fatalf("TakeRateLimitToken(%q, %q): %v", class, client, err)
+		}
+
+		return result
+	}
+
+	for i := range 2 {
+		if got := take(models.RateLimitRead, "a"); !got.Allowed || got.Remaining != 1-i {
+			t.Fatalf("read %d = %+v", i, got)
+		}
+	}
+
+	if got := take(models.RateLimitRead, "a"); got.Allowed || got.RetryAfter != 5*time.Second {
+		t.Fatalf("read of an exhausted client = %+v", got)
+	}
+
+	// Classes and clients have buckets of their own.
+	if got := take(models.RateLimitWrite, "a"); !got.Allowed || got.Limit.Requests != 1 {
+		t.Errorf("write of a client out of reads = %+v", got)
+	}
+
+	if got := take(models.RateLimitRead, "b"); !got.Allowed {
+		t.Errorf("read of another client = %+v", got)
+	}
+
+	now = now.Add(5 * time.Second)
+
+	if got := take(models.RateLimitRead, "a"); !got.Allowed || got.Remaining != 0 {
+		t.Errorf("read after a refill = %+v", got)
+	}
+
+	// Classes without a limit let everything through.
+	for range 3 {
+		if got := take(models.RateLimitAuth, "a"); !got.Allowed || got.Limit.Enabled() {
+			t.Fatalf("token of a class without a limit = %+v", got)
+		}
+	}
+}
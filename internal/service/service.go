@@ -2,15 +2,29 @@ package service
 
 import (
 	"log/slog"
+	"music/internal/config"
 	"music/internal/repository"
 )
 
 type Service struct {
 	Music
+	Artist
+	Import
+	Idempotency
+	Auth
+	RateLimit
 }
 
-func NewService(repos *repository.Repository, logger *slog.Logger) *Service {
+func NewService(
+	repos *repository.Repository, scheduler EnrichmentScheduler, cfg config.Config, logger *slog.Logger,
+) *Service {
+	// Every service method runs in a span, see traced.go.
 	return &Service{
-		Music: newMusicService(repos.Music, logger),
+		Music:       &tracedMusic{newMusicService(repos.Music, repos.Enrichment, repos.Search, scheduler)},
+		Artist:      &tracedArtist{newArtistService(repos.Artist)},
+		Import:      &tracedImport{newImportService(repos.Music, scheduler)},
+		Idempotency: &tracedIdempotency{newIdempotencyService(repos.Idempotency, cfg.IdempotencyTTL, logger)},
+		Auth:        &tracedAuth{newAuthService(repos.APIKey, cfg, logger)},
+		RateLimit:   &tracedRateLimit{newRateLimitService(repos.RateLimit, cfg, logger)},
 	}
 }
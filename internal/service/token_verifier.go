@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"music/internal/config"
+	"music/internal/models"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const minRSAKeyBits = 2048
+
+// tokenVerifier authenticates JWTs. HS256 tokens are signed with a shared
+// secret, RS256 ones with a key of a JWKS file, chosen by the kid header.
+// Tokens must have a subject and an expiry, the role is read from
+// roleClaim and defaults to read-only.
+type tokenVerifier struct {
+	secret    []byte
+	keys      map[string]*rsa.PublicKey
+	issuer    string
+	audience  string
+	roleClaim string
+	leeway    time.Duration
+	now       func() time.Time
+}
+
+// newTokenVerifier returns nil when neither a secret nor a JWKS file is
+// configured, so that tokens are not accepted.
+func newTokenVerifier(cfg config.Config) (*tokenVerifier, error) {
+	if cfg.AuthJWTSecret == "" && cfg.AuthJWKSFile == "" {
+		return nil, nil
+	}
+
+	v := &tokenVerifier{
+		issuer:    cfg.AuthJWTIssuer,
+		audience:  cfg.AuthJWTAudience,
+		roleClaim: cfg.AuthJWTRoleClaim,
+		leeway:    cfg.AuthJWTLeeway,
+		now:       time.Now,
+	}
+
+	if cfg.AuthJWTSecret != "" {
+		v.secret = []byte(cfg.AuthJWTSecret)
+	}
+
+	if cfg.AuthJWKSFile != "" {
+		keys, err := loadJWKS(cfg.AuthJWKSFile)
+		if err != nil {
+			return v, fmt.Errorf("failed to load JWKS: %w", err)
+		}
+
+		v.keys = keys
+	}
+
+	return v, nil
+}
+
+func (v *tokenVerifier) Authenticate(ctx context.Context, token string) (models.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return models.Principal{}, invalidToken("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return models.Principal{}, invalidToken("malformed header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return models.Principal{}, invalidToken("malformed signature")
+	}
+
+	if err := v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return models.Principal{}, err
+	}
+
+	var claims map[string]any
+
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return models.Principal{}, invalidToken("malformed claims")
+	}
+
+	return v.principal(claims)
+}
+
+func (v *tokenVerifier) verifySignature(alg, kid, signed string, signature []byte) error {
+	switch {
+	case alg == "HS256" && v.secret != nil:
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return invalidToken("invalid signature")
+		}
+	case alg == "RS256" && v.keys != nil:
+		key := v.keys[kid]
+		if key == nil && kid == "" && len(v.keys) == 1 {
+			for _, only := range v.keys {
+				key = only
+			}
+		}
+
+		if key == nil {
+			return invalidToken("unknown signing key")
+		}
+
+		digest := sha256.Sum256([]byte(signed))
+
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return invalidToken("invalid signature")
+		}
+	default:
+		return invalidToken(fmt.Sprintf("unsupported algorithm %q", alg))
+	}
+
+	return nil
+}
+
+func (v *tokenVerifier) principal(claims map[string]any) (models.Principal, error) {
+	now := v.now()
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return models.Principal{}, invalidToken("no subject")
+	}
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return models.Principal{}, invalidToken("no expiry")
+	}
+
+	if now.After(exp.Add(v.leeway)) {
+		return models.Principal{}, invalidToken("expired")
+	}
+
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(v.leeway).Before(nbf) {
+		return models.Principal{}, invalidToken("not valid yet")
+	}
+
+	if v.issuer != "" && claims["iss"] != v.issuer {
+		return models.Principal{}, invalidToken("wrong issuer")
+	}
+
+	if v.audience != "" && !hasAudience(claims["aud"], v.audience) {
+		return models.Principal{}, invalidToken("wrong audience")
+	}
+
+	role := models.RoleReadOnly
+
+	if claim, ok := claims[v.roleClaim]; ok {
+		name, _ := claim.(string)
+
+		role = models.Role(name)
+		if !role.Valid() {
+			return models.Principal{}, invalidToken("unknown role")
+		}
+	}
+
+	return models.Principal{Subject: subject, Role: role, Method: models.AuthToken}, nil
+}
+
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: invalid token: %s", models.ErrUnauthorized, reason)
+}
+
+// decodeSegment decodes a base64url encoded JSON part of a token, numbers
+// are kept as json.Number.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	return decoder.Decode(v)
+}
+
+// numericClaim returns a time claim, in seconds since the epoch.
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(seconds), 0), true
+}
+
+// hasAudience reports whether the aud claim, a string or an array of them,
+// names audience.
+func hasAudience(claim any, audience string) bool {
+	switch aud := claim.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		return slices.Contains(aud, any(audience))
+	default:
+		return false
+	}
+}
+
+// loadJWKS reads the RSA signing keys of a JSON Web Key Set by their kid.
+// Keys of other types or uses are skipped.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != "RS256") {
+			continue
+		}
+
+		key, err := rsaPublicKey(jwk.N, jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no RS256 signing keys")
+	}
+
+	return keys, nil
+}
+
+func rsaPublicKey(modulus, exponent string) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(modulus)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(exponent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n)}
+
+	if key.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("modulus must be at least %d bits", minRSAKeyBits)
+	}
+
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid exponent")
+	}
+
+	key.E = int(exp.Int64())
+
+	return key, nil
+}
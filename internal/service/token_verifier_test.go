@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"music/internal/config"
+	"music/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+var (
+	testNow = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	rsaKeysOnce sync.Once
+	rsaKey      *rsa.PrivateKey
+	otherRSAKey *rsa.PrivateKey
+)
+
+// testRSAKeys returns the key of the test JWKS and a key that is not in it.
+func testRSAKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
+	t.Helper()
+
+	rsaKeysOnce.Do(func() {
+		var err error
+		if rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+		if otherRSAKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+	})
+
+	return rsaKey, otherRSAKey
+}
+
+// writeJWKS writes a JWKS with key as kid and returns its path.
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	t.Helper()
+
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// hs256 returns a token signed with secret.
+func hs256(t *testing.T, header, claims map[string]any, secret string) string {
+	t.Helper()
+
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// rs256 returns a token signed with key.
+func rs256(t *testing.T, header, claims map[string]any, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// claims returns valid claims with changes applied, nil values remove a
+// claim.
+func claims(changes map[string]any) map[string]any {
+	c := map[string]any{
+		"sub": "alice",
+		"exp": testNow.Add(time.Hour).Unix(),
+		"iss": "https://issuer.example",
+		"aud": "music",
+	}
+
+	for name, value := range changes {
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+	}
+
+	return c
+}
+
+func newTestVerifier(t *testing.T, cfg config.Config) *tokenVerifier {
+	t.Helper()
+
+	cfg.AuthJWTIssuer = "https://issuer.example"
+	cfg.AuthJWTAudience = "music"
+	cfg.AuthJWTRoleClaim = "role"
+	cfg.AuthJWTLeeway = time.Minute
+
+	v, err := newTokenVerifier(cfg)
+	if err != nil {
+		t.Fatalf("newTokenVerifier: %v", err)
+	}
+
+	v.now = func() time.Time { return testNow }
+
+	return v
+}
+
+func TestTokenVerifierSignatures(t *testing.T) {
+	key, other := testRSAKeys(t)
+
+	hsOnly := newTestVerifier(t, config.Config{AuthJWTSecret: testSecret})
+	jwksOnly := newTestVerifier(t, config.Config{AuthJWKSFile: writeJWKS(t, "k1", &key.PublicKey)})
+
+	hs := map[string]any{"alg": "HS256", "typ": "JWT"}
+	rs := map[string]any{"alg": "RS256", "kid": "k1"}
+
+	// A token whose claims were changed after it was signed.
+	parts := strings.Split(hs256(t, hs, claims(nil), testSecret), ".")
+	tampered := parts[0] + "." + encodeSegment(t, claims(map[string]any{"role": "admin"})) + "." + parts[2]
+
+	tests := []struct {
+		name     string
+		verifier *tokenVerifier
+		token    string
+		wantErr  bool
+	}{
+		{"HS256", hsOnly, hs256(t, hs, claims(nil), testSecret), false},
+		{"HS256 with another secret", hsOnly, hs256(t, hs, claims(nil), "other"), true},
+		{"HS256 with changed claims", hsOnly, tampered, true},
+		{"alg none", hsOnly, encodeSegment(t, map[string]any{"alg": "none"}) + "." + encodeSegment(t, claims(nil)) + ".", true},
+		{"alg none signed", hsOnly, hs256(t, map[string]any{"alg": "none"}, claims(nil), testSecret), true},
+		{"HS512", hsOnly, hs256(t, map[string]any{"alg": "HS512"}, claims(nil), testSecret), true},
+		{"RS256 without a JWKS", hsOnly, rs256(t, rs, claims(nil), key), true},
+		{"RS256", jwksOnly, rs256(t, rs, claims(nil), key), false},
+		{"RS256 without kid and a single key", jwksOnly, rs256(t, map[string]any{"alg": "RS256"}, claims(nil), key), false},
+		{"RS256 with another key", jwksOnly, rs256(t, rs, claims(nil), other), true},
+		{"RS256 with an unknown kid", jwksOnly, rs256(t, map[string]any{"alg": "RS256", "kid": "k2"}, claims(nil), key), true},
+		{"HS256 without a secret", jwksOnly, hs256(t, hs, claims(nil), testSecret), true},
+		{"HS256 signed with the public key", jwksOnly, hs256(t, hs, claims(nil), string(key.N.Bytes())), true},
+		{"malformed", hsOnly, "not.a-token", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tt.verifier.Authenticate(context.Background(), tt.token)
+
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrUnauthorized) {
+					t.Errorf("Authenticate = %+v, %v, want %v", principal, err, models.ErrUnauthorized)
+				}
+				return
+			}
+
+			if err != nil || principal.Subject != "alice" || principal.Method != models.AuthToken {
+				t.Errorf("Authenticate = %+v, %v, want alice by token", principal, err)
+			}
+		})
+	}
+}
+
+func TestTokenVerifierClaims(t *testing.T) {
+	v := newTestVerifier(t, config.Config{AuthJWTSecret: testSecret})
+	header := map[string]any{"alg": "HS256"}
+
+	tests := []struct {
+		name     string
+		changes  map[string]any
+		wantRole models.Role
+		wantErr  string
+	}{
+		{"valid", nil, models.RoleReadOnly, ""},
+		{"editor role", map[string]any{"role": "editor"}, models.RoleEditor, ""},
+		{"admin role", map[string]any{"role": "admin"}, models.RoleAdmin, ""},
+		{"unknown role", map[string]any{"role": "root"}, "", "unknown role"},
+		{"role of another type", map[string]any{"role": 1}, "", "unknown role"},
+		{"expired", map[string]any{"exp": testNow.Add(-2 * time.Minute).Unix()}, "", "expired"},
+		{"expired within the leeway", map[string]any{"exp": testNow.Add(-30 * time.Second).Unix()}, models.RoleReadOnly, ""},
+		{"not valid yet", map[string]any{"nbf": testNow.Add(2 * time.Minute).Unix()}, "", "not valid yet"},
+		{"not valid yet within the leeway", map[string]any{"nbf": testNow.Add(30 * time.Second).Unix()}, models.RoleReadOnly, ""},
+		{"no subject", map[string]any{"sub": nil}, "", "no subject"},
+		{"empty subject", map[string]any{"sub": ""}, "", "no subject"},
+		{"no expiry", map[string]any{"exp": nil}, "", "no expiry"},
+		{"expiry of another type", map[string]any{"exp": "tomorrow"}, "", "no expiry"},
+		{"wrong issuer", map[string]any{"iss": "https://other.example"}, "", "wrong issuer"},
+		{"no issuer", map[string]any{"iss": nil}, "", "wrong issuer"},
+		{"wrong audience", map[string]any{"aud": "other"}, "", "wrong audience"},
+		{"no audience", map[string]any{"aud": nil}, "", "wrong audience"},
+		{"audience in an array", map[string]any{"aud": []string{"other", "music"}}, models.RoleReadOnly, ""},
+		{"audience missing from an array", map[string]any{"aud": []string{"other"}}, "", "wrong audience"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := v.Authenticate(context.Background(), hs256(t, header, claims(tt.changes), testSecret))
+
+			if tt.wantErr != "" {
+				if !errors.Is(err, models.ErrUnauthorized) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Authenticate = %+v, %v, want %q", principal, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil || principal.Role != tt.wantRole {
+				t.Errorf("Authenticate = %+v, %v, want role %s", principal, err, tt.wantRole)
+			}
+		})
+	}
+}
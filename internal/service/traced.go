@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"io"
+	"music/internal/models"
+	"music/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "music/internal/service"
+
+// startSpan starts the span of a service method, such as Music.GetMusic. The
+// tracer is taken from the provider installed at the time, as the one of
+// tracing.Setup or of a test.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal))
+}
+
+// tracedMusic runs every method of the wrapped Music in a span of its own.
+type tracedMusic struct {
+	music Music
+}
+
+func (s *tracedMusic) GetMusics(ctx context.Context, filter models.MusicFilter) ([]models.MusicInfo, error) {
+	ctx, span := startSpan(ctx, "Music.GetMusics")
+	musics, err := s.music.GetMusics(ctx, filter)
+	tracing.End(span, err)
+
+	return musics, err
+}
+
+func (s *tracedMusic) GetMusicsPage(
+	ctx context.Context, filter models.MusicFilter, cursor string,
+) (models.MusicPage, error) {
+	ctx, span := startSpan(ctx, "Music.GetMusicsPage")
+	page, err := s.music.GetMusicsPage(ctx, filter, cursor)
+	tracing.End(span, err)
+
+	return page, err
+}
+
+func (s *tracedMusic) ExportMusics(
+	ctx context.Context, filter models.MusicFilter, yield func(models.MusicInfo) error,
+) error {
+	ctx, span := startSpan(ctx, "Music.ExportMusics")
+	err := s.music.ExportMusics(ctx, filter, yield)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedMusic) GetMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	ctx, span := startSpan(ctx, "Music.GetMusic")
+	music, err := s.music.GetMusic(ctx, ID)
+	tracing.End(span, err)
+
+	return music, err
+}
+
+func (s *tracedMusic) GetSongLyricsByVerses(ctx context.Context, ID, couplet, size int) (models.Lyrics, error) {
+	ctx, span := startSpan(ctx, "Music.GetSongLyricsByVerses")
+	lyrics, err := s.music.GetSongLyricsByVerses(ctx, ID, couplet, size)
+	tracing.End(span, err)
+
+	return lyrics, err
+}
+
+func (s *tracedMusic) AddMusic(ctx context.Context, music models.Music) (models.MusicInfo, error) {
+	ctx, span := startSpan(ctx, "Music.AddMusic")
+	stored, err := s.music.AddMusic(ctx, music)
+	tracing.End(span, err)
+
+	return stored, err
+}
+
+func (s *tracedMusic) GetEnrichmentStatus(ctx context.Context, ID int) (models.EnrichmentStatus, error) {
+	ctx, span := startSpan(ctx, "Music.GetEnrichmentStatus")
+	status, err := s.music.GetEnrichmentStatus(ctx, ID)
+	tracing.End(span, err)
+
+	return status, err
+}
+
+func (s *tracedMusic) UpdateMusic(
+	ctx context.Context, ID int, updates models.MusicUpdate, version int,
+) (models.MusicInfo, error) {
+	ctx, span := startSpan(ctx, "Music.UpdateMusic")
+	music, err := s.music.UpdateMusic(ctx, ID, updates, version)
+	tracing.End(span, err)
+
+	return music, err
+}
+
+func (s *tracedMusic) DeleteMusic(ctx context.Context, ID, version int) error {
+	ctx, span := startSpan(ctx, "Music.DeleteMusic")
+	err := s.music.DeleteMusic(ctx, ID, version)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedMusic) GetDeletedMusics(ctx context.Context, limit, offset int) ([]models.DeletedMusic, error) {
+	ctx, span := startSpan(ctx, "Music.GetDeletedMusics")
+	musics, err := s.music.GetDeletedMusics(ctx, limit, offset)
+	tracing.End(span, err)
+
+	return musics, err
+}
+
+func (s *tracedMusic) RestoreMusic(ctx context.Context, ID int) (models.MusicInfo, error) {
+	ctx, span := startSpan(ctx, "Music.RestoreMusic")
+	music, err := s.music.RestoreMusic(ctx, ID)
+	tracing.End(span, err)
+
+	return music, err
+}
+
+func (s *tracedMusic) GetMusicHistory(ctx context.Context, ID, limit, offset int) ([]models.Revision, error) {
+	ctx, span := startSpan(ctx, "Music.GetMusicHistory")
+	revisions, err := s.music.GetMusicHistory(ctx, ID, limit, offset)
+	tracing.End(span, err)
+
+	return revisions, err
+}
+
+func (s *tracedMusic) RevertMusic(ctx context.Context, ID, rev, version int) (models.MusicInfo, error) {
+	ctx, span := startSpan(ctx, "Music.RevertMusic")
+	music, err := s.music.RevertMusic(ctx, ID, rev, version)
+	tracing.End(span, err)
+
+	return music, err
+}
+
+func (s *tracedMusic) SearchMusics(ctx context.Context, query models.SearchQuery) ([]models.SearchResult, error) {
+	ctx, span := startSpan(ctx, "Music.SearchMusics")
+	results, err := s.music.SearchMusics(ctx, query)
+	tracing.End(span, err)
+
+	return results, err
+}
+
+// tracedArtist runs every method of the wrapped Artist in a span of its own.
+type tracedArtist struct {
+	artist Artist
+}
+
+func (s *tracedArtist) GetArtists(ctx context.Context, limit, offset int) ([]models.Artist, error) {
+	ctx, span := startSpan(ctx, "Artist.GetArtists")
+	artists, err := s.artist.GetArtists(ctx, limit, offset)
+	tracing.End(span, err)
+
+	return artists, err
+}
+
+func (s *tracedArtist) GetArtist(ctx context.Context, ID int) (models.Artist, error) {
+	ctx, span := startSpan(ctx, "Artist.GetArtist")
+	artist, err := s.artist.GetArtist(ctx, ID)
+	tracing.End(span, err)
+
+	return artist, err
+}
+
+func (s *tracedArtist) GetArtistSongs(ctx context.Context, ID, limit, offset int) ([]models.MusicInfo, error) {
+	ctx, span := startSpan(ctx, "Artist.GetArtistSongs")
+	musics, err := s.artist.GetArtistSongs(ctx, ID, limit, offset)
+	tracing.End(span, err)
+
+	return musics, err
+}
+
+func (s *tracedArtist) AddArtist(ctx context.Context, name string) (models.Artist, error) {
+	ctx, span := startSpan(ctx, "Artist.AddArtist")
+	artist, err := s.artist.AddArtist(ctx, name)
+	tracing.End(span, err)
+
+	return artist, err
+}
+
+func (s *tracedArtist) RenameArtist(ctx context.Context, ID int, name string) (models.Artist, error) {
+	ctx, span := startSpan(ctx, "Artist.RenameArtist")
+	artist, err := s.artist.RenameArtist(ctx, ID, name)
+	tracing.End(span, err)
+
+	return artist, err
+}
+
+func (s *tracedArtist) DeleteArtist(ctx context.Context, ID int) error {
+	ctx, span := startSpan(ctx, "Artist.DeleteArtist")
+	err := s.artist.DeleteArtist(ctx, ID)
+	tracing.End(span, err)
+
+	return err
+}
+
+// tracedImport runs the imports in a span.
+type tracedImport struct {
+	imports Import
+}
+
+func (s *tracedImport) ImportMusics(
+	ctx context.Context, r io.Reader, format models.ImportFormat,
+) (models.ImportReport, error) {
+	ctx, span := startSpan(ctx, "Import.ImportMusics")
+	report, err := s.imports.ImportMusics(ctx, r, format)
+	tracing.End(span, err)
+
+	return report, err
+}
+
+// tracedIdempotency runs every method of the wrapped Idempotency in a span
+// of its own.
+type tracedIdempotency struct {
+	idempotency Idempotency
+}
+
+func (s *tracedIdempotency) ReserveIdempotencyKey(
+	ctx context.Context, key, fingerprint string,
+) (models.IdempotencyRecord, bool, error) {
+	ctx, span := startSpan(ctx, "Idempotency.ReserveIdempotencyKey")
+	record, reserved, err := s.idempotency.ReserveIdempotencyKey(ctx, key, fingerprint)
+	tracing.End(span, err)
+
+	return record, reserved, err
+}
+
+func (s *tracedIdempotency) CompleteIdempotencyKey(
+	ctx context.Context, key string, record models.IdempotencyRecord,
+) error {
+	ctx, span := startSpan(ctx, "Idempotency.CompleteIdempotencyKey")
+	err := s.idempotency.CompleteIdempotencyKey(ctx, key, record)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (s *tracedIdempotency) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	ctx, span := startSpan(ctx, "Idempotency.ReleaseIdempotencyKey")
+	err := s.idempotency.ReleaseIdempotencyKey(ctx, key)
+	tracing.End(span, err)
+
+	return err
+}
+
+// tracedAuth runs every method of the wrapped Auth in a span of its own.
+type tracedAuth struct {
+	auth Auth
+}
+
+func (s *tracedAuth) Authenticate(ctx context.Context, credentials models.Credentials) (models.Principal, error) {
+	ctx, span := startSpan(ctx, "Auth.Authenticate")
+	principal, err := s.auth.Authenticate(ctx, credentials)
+	tracing.End(span, err)
+
+	return principal, err
+}
+
+func (s *tracedAuth) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ctx, span := startSpan(ctx, "Auth.GetAPIKeys")
+	keys, err := s.auth.GetAPIKeys(ctx)
+	tracing.End(span, err)
+
+	return keys, err
+}
+
+func (s *tracedAuth) AddAPIKey(ctx context.Context, name string, role models.Role) (models.APIKey, error) {
+	ctx, span := startSpan(ctx, "Auth.AddAPIKey")
+	key, err := s.auth.AddAPIKey(ctx, name, role)
+	tracing.End(span, err)
+
+	return key, err
+}
+
+func (s *tracedAuth) RevokeAPIKey(ctx context.Context, ID int) error {
+	ctx, span := startSpan(ctx, "Auth.RevokeAPIKey")
+	err := s.auth.RevokeAPIKey(ctx, ID)
+	tracing.End(span, err)
+
+	return err
+}
+
+// tracedRateLimit runs the rate limit checks in a span.
+type tracedRateLimit struct {
+	rateLimit RateLimit
+}
+
+func (s *tracedRateLimit) TakeRateLimitToken(
+	ctx context.Context, class, client string,
+) (models.RateLimitResult, error) {
+	ctx, span := startSpan(ctx, "RateLimit.TakeRateLimitToken")
+	result, err := s.rateLimit.TakeRateLimitToken(ctx, class, client)
+	tracing.End(span, err)
+
+	return result, err
+}
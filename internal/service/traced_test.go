@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"music/internal/tracing/tracingtest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// discardScheduler drops the songs queued for enrichment.
+type discardScheduler struct{}
+
+func (discardScheduler) Enqueue(ctx context.Context, task models.EnrichmentTask) {}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// findSpan returns the ended span called name.
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	t.Fatalf("no %s span in %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func TestServiceSpans(t *testing.T) {
+	exporter := tracingtest.Record(t)
+	services := NewService(repository.NewMemoryRepository(), discardScheduler{}, config.Config{}, discardLogger())
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+
+	stored, err := services.Music.AddMusic(ctx, models.Music{Group: "Muse", Song: "Uprising"})
+	if err != nil {
+		t.Fatalf("AddMusic: %v", err)
+	}
+
+	if _, err := services.Music.GetMusic(ctx, stored.ID+1); !errors.Is(err, models.ErrNotFound) {
+		t.Fatalf("GetMusic of a missing song: got %v, want %v", err, models.ErrNotFound)
+	}
+
+	parent.End()
+	spans := exporter.GetSpans()
+
+	added := findSpan(t, spans, "Music.AddMusic")
+	if added.Parent.SpanID() != parent.SpanContext().SpanID() || added.Status.Code == codes.Error {
+		t.Errorf("Music.AddMusic span has parent %s and status %v, want a successful child of the request",
+			added.Parent.SpanID(), added.Status)
+	}
+
+	missing := findSpan(t, spans, "Music.GetMusic")
+	if missing.Parent.SpanID() != parent.SpanContext().SpanID() || missing.Status.Code != codes.Error ||
+		len(missing.Events) == 0 {
+		t.Errorf("Music.GetMusic span has parent %s, status %v and events %v, want a failed child of the request",
+			missing.Parent.SpanID(), missing.Status, missing.Events)
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"music/internal/config"
+	"music/internal/models"
+	"music/internal/repository"
+	"sync"
+	"time"
+)
+
+const (
+	purgeTimeout              = time.Minute
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+	// trashActor is the actor of the purge revisions.
+	trashActor = "trash"
+)
+
+// TrashPurger periodically removes the songs that stayed in the trash for
+// longer than the retention.
+type TrashPurger struct {
+	repos  repository.Music
+	logger *slog.Logger
+
+	retention time.Duration
+	interval  time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewTrashPurger(repos repository.Music, cfg config.Config, logger *slog.Logger) *TrashPurger {
+	ctx, cancel := context.WithCancel(models.WithAudit(context.Background(), models.Audit{Actor: trashActor}))
+
+	retention := cfg.TrashRetention
+	if retention <= 0 {
+		retention = defaultTrashRetention
+	}
+
+	interval := cfg.TrashPurgeInterval
+	if interval <= 0 {
+		interval = defaultTrashPurgeInterval
+	}
+
+	return &TrashPurger{
+		repos:     repos,
+		logger:    logger,
+		retention: retention,
+		interval:  interval,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start runs the purge right away and then every interval until Stop is
+// called.
+func (p *TrashPurger) Start() {
+	p.wg.Add(1)
+
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.purge()
+
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop interrupts a running purge and waits for it or for ctx to expire.
+func (p *TrashPurger) Stop(ctx context.Context) error {
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *TrashPurger) purge() {
+	ctx, cancel := context.WithTimeout(p.ctx, purgeTimeout)
+	defer cancel()
+
+	purged, err := p.repos.PurgeMusics(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		if p.ctx.Err() == nil {
+			p.logger.Error("Failed to purge the trash", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	if purged > 0 {
+		p.logger.Info("Purged the trash", slog.Int("songs", purged))
+	}
+}
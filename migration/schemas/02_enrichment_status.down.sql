@@ -0,0 +1,16 @@
+DROP INDEX musics_pending_enrichment_idx;
+
+UPDATE musics SET release_date = CURRENT_DATE WHERE release_date IS NULL;
+
+ALTER TABLE musics
+    ALTER COLUMN release_date SET NOT NULL,
+    ALTER COLUMN release_date SET DEFAULT CURRENT_DATE,
+    ALTER COLUMN text DROP DEFAULT,
+    ALTER COLUMN link DROP DEFAULT;
+
+ALTER TABLE musics
+    DROP COLUMN enrichment_status,
+    DROP COLUMN enrichment_attempts,
+    DROP COLUMN enrichment_error,
+    DROP COLUMN enrichment_next_at,
+    DROP COLUMN enriched_at;
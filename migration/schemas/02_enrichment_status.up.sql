@@ -0,0 +1,16 @@
+ALTER TABLE musics
+    ADD COLUMN enrichment_status VARCHAR(16) NOT NULL DEFAULT 'enriched',
+    ADD COLUMN enrichment_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN enrichment_error TEXT NOT NULL DEFAULT '',
+    ADD COLUMN enrichment_next_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN enriched_at TIMESTAMPTZ;
+
+ALTER TABLE musics
+    ALTER COLUMN enrichment_status SET DEFAULT 'pending',
+    ALTER COLUMN release_date DROP NOT NULL,
+    ALTER COLUMN release_date DROP DEFAULT,
+    ALTER COLUMN text SET DEFAULT '',
+    ALTER COLUMN link SET DEFAULT '';
+
+CREATE INDEX musics_pending_enrichment_idx ON musics (enrichment_next_at)
+    WHERE enrichment_status = 'pending';
@@ -0,0 +1,33 @@
+CREATE TABLE musics (
+    id SERIAL PRIMARY KEY,
+    music_group VARCHAR(255) NOT NULL,
+    song VARCHAR(255) NOT NULL,
+    release_date DATE,
+    text TEXT NOT NULL DEFAULT '',
+    link VARCHAR(255) NOT NULL DEFAULT '',
+    enrichment_status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    enrichment_attempts INT NOT NULL DEFAULT 0,
+    enrichment_error TEXT NOT NULL DEFAULT '',
+    enrichment_next_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    enriched_at TIMESTAMPTZ
+);
+
+CREATE INDEX musics_pending_enrichment_idx ON musics (enrichment_next_at)
+    WHERE enrichment_status = 'pending';
+
+INSERT INTO musics (
+    id, music_group, song, release_date, text, link,
+    enrichment_status, enrichment_attempts, enrichment_error, enrichment_next_at, enriched_at
+)
+SELECT s.id, a.name, s.song, s.release_date,
+       COALESCE((SELECT string_agg(v.text, '\n\n' ORDER BY v.position) FROM verses v WHERE v.song_id = s.id), ''),
+       s.link,
+       s.enrichment_status, s.enrichment_attempts, s.enrichment_error, s.enrichment_next_at, s.enriched_at
+FROM songs s
+JOIN artists a ON a.id = s.artist_id;
+
+SELECT setval(pg_get_serial_sequence('musics', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM musics;
+
+DROP TABLE verses;
+DROP TABLE songs;
+DROP TABLE artists;
@@ -0,0 +1,54 @@
+CREATE TABLE artists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE
+);
+
+CREATE TABLE songs (
+    id SERIAL PRIMARY KEY,
+    artist_id INT NOT NULL REFERENCES artists (id) ON DELETE RESTRICT,
+    song VARCHAR(255) NOT NULL,
+    release_date DATE,
+    link VARCHAR(255) NOT NULL DEFAULT '',
+    enrichment_status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    enrichment_attempts INT NOT NULL DEFAULT 0,
+    enrichment_error TEXT NOT NULL DEFAULT '',
+    enrichment_next_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    enriched_at TIMESTAMPTZ
+);
+
+CREATE INDEX songs_artist_id_idx ON songs (artist_id);
+
+CREATE INDEX songs_pending_enrichment_idx ON songs (enrichment_next_at)
+    WHERE enrichment_status = 'pending';
+
+-- Verses of a song in reading order, position starts at 1.
+CREATE TABLE verses (
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    text TEXT NOT NULL,
+    PRIMARY KEY (song_id, position)
+);
+
+-- Backfill, keeping song IDs so existing links stay valid.
+INSERT INTO artists (name)
+SELECT DISTINCT music_group FROM musics;
+
+INSERT INTO songs (
+    id, artist_id, song, release_date, link,
+    enrichment_status, enrichment_attempts, enrichment_error, enrichment_next_at, enriched_at
+)
+SELECT m.id, a.id, m.song, m.release_date, m.link,
+       m.enrichment_status, m.enrichment_attempts, m.enrichment_error, m.enrichment_next_at, m.enriched_at
+FROM musics m
+JOIN artists a ON a.name = m.music_group;
+
+SELECT setval(pg_get_serial_sequence('songs', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM songs;
+
+-- Lyrics were stored as one blob with verses separated by a literal "\n\n".
+INSERT INTO verses (song_id, position, text)
+SELECT m.id, v.position, v.text
+FROM musics m
+CROSS JOIN LATERAL regexp_split_to_table(m.text, '\\n\\n') WITH ORDINALITY AS v (text, position)
+WHERE m.text <> '';
+
+DROP TABLE musics;
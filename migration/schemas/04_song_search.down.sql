@@ -0,0 +1,17 @@
+DROP TRIGGER artists_search_vector_trg ON artists;
+DROP FUNCTION artists_refresh_search_vector();
+
+DROP TRIGGER verses_delete_search_vector_trg ON verses;
+DROP TRIGGER verses_update_search_vector_trg ON verses;
+DROP TRIGGER verses_insert_search_vector_trg ON verses;
+DROP FUNCTION verses_refresh_search_vector();
+
+DROP TRIGGER songs_search_vector_trg ON songs;
+DROP FUNCTION songs_refresh_search_vector();
+DROP FUNCTION song_search_vector(REGCONFIG, TEXT, TEXT, TEXT);
+
+DROP INDEX songs_search_vector_idx;
+
+ALTER TABLE songs
+    DROP COLUMN search_vector,
+    DROP COLUMN search_language;
@@ -0,0 +1,75 @@
+ALTER TABLE songs
+    ADD COLUMN search_language REGCONFIG NOT NULL DEFAULT 'simple',
+    ADD COLUMN search_vector TSVECTOR NOT NULL DEFAULT ''::TSVECTOR;
+
+CREATE INDEX songs_search_vector_idx ON songs USING GIN (search_vector);
+
+-- Titles rank above group names, which rank above lyrics. Lyrics may contain
+-- escaped line breaks ("\n" as two characters), which must not glue words.
+CREATE FUNCTION song_search_vector(lang REGCONFIG, artist TEXT, title TEXT, lyrics TEXT)
+RETURNS TSVECTOR
+LANGUAGE SQL IMMUTABLE AS $$
+    SELECT setweight(to_tsvector(lang, COALESCE(title, '')), 'A')
+        || setweight(to_tsvector(lang, COALESCE(artist, '')), 'B')
+        || setweight(to_tsvector(lang, regexp_replace(COALESCE(lyrics, ''), '\\[nr]', ' ', 'g')), 'C');
+$$;
+
+CREATE FUNCTION songs_refresh_search_vector() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search_vector := song_search_vector(
+        NEW.search_language,
+        (SELECT name FROM artists WHERE id = NEW.artist_id),
+        NEW.song,
+        (SELECT string_agg(text, ' ' ORDER BY position) FROM verses WHERE song_id = NEW.id)
+    );
+
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER songs_search_vector_trg
+    BEFORE INSERT OR UPDATE OF artist_id, song, search_language ON songs
+    FOR EACH ROW EXECUTE FUNCTION songs_refresh_search_vector();
+
+-- Verses and artists live in their own tables, touching search_language of
+-- the affected songs makes the trigger above recompute their vectors.
+CREATE FUNCTION verses_refresh_search_vector() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE songs SET search_language = search_language
+    WHERE id IN (SELECT DISTINCT song_id FROM changed_verses);
+
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER verses_insert_search_vector_trg
+    AFTER INSERT ON verses
+    REFERENCING NEW TABLE AS changed_verses
+    FOR EACH STATEMENT EXECUTE FUNCTION verses_refresh_search_vector();
+
+CREATE TRIGGER verses_update_search_vector_trg
+    AFTER UPDATE ON verses
+    REFERENCING NEW TABLE AS changed_verses
+    FOR EACH STATEMENT EXECUTE FUNCTION verses_refresh_search_vector();
+
+CREATE TRIGGER verses_delete_search_vector_trg
+    AFTER DELETE ON verses
+    REFERENCING OLD TABLE AS changed_verses
+    FOR EACH STATEMENT EXECUTE FUNCTION verses_refresh_search_vector();
+
+CREATE FUNCTION artists_refresh_search_vector() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE songs SET search_language = search_language WHERE artist_id = NEW.id;
+
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER artists_search_vector_trg
+    AFTER UPDATE OF name ON artists
+    FOR EACH ROW EXECUTE FUNCTION artists_refresh_search_vector();
+
+UPDATE songs SET search_language = search_language;
@@ -0,0 +1,2 @@
+DROP INDEX songs_artist_song_key;
+DROP INDEX artists_name_lower_key;
@@ -0,0 +1,44 @@
+-- Artists and songs become unique ignoring case. Existing duplicates are
+-- merged into the oldest row first.
+UPDATE songs s
+SET artist_id = d.keep_id
+FROM (SELECT id, MIN(id) OVER (PARTITION BY lower(name)) AS keep_id FROM artists) d
+WHERE s.artist_id = d.id AND d.id <> d.keep_id;
+
+DELETE FROM artists a
+USING artists b
+WHERE lower(a.name) = lower(b.name) AND a.id > b.id;
+
+-- A kept song takes the release date, link and lyrics it lacks from the
+-- oldest duplicate that has them. Only values it already has are dropped
+-- with the duplicates.
+UPDATE songs k
+SET release_date = COALESCE(k.release_date, d.release_date),
+    link = CASE WHEN k.link = '' THEN COALESCE(d.link, '') ELSE k.link END
+FROM (
+    SELECT keep_id,
+           (array_agg(release_date ORDER BY id) FILTER (WHERE release_date IS NOT NULL))[1] AS release_date,
+           (array_agg(link ORDER BY id) FILTER (WHERE link <> ''))[1] AS link
+    FROM (SELECT id, release_date, link, MIN(id) OVER (PARTITION BY artist_id, lower(song)) AS keep_id FROM songs) s
+    WHERE id <> keep_id
+    GROUP BY keep_id
+) d
+WHERE k.id = d.keep_id;
+
+UPDATE verses v
+SET song_id = d.keep_id
+FROM (
+    SELECT DISTINCT ON (keep_id) keep_id, id
+    FROM (SELECT id, MIN(id) OVER (PARTITION BY artist_id, lower(song)) AS keep_id FROM songs) s
+    WHERE id <> keep_id AND EXISTS (SELECT 1 FROM verses WHERE song_id = s.id)
+    ORDER BY keep_id, id
+) d
+WHERE v.song_id = d.id AND NOT EXISTS (SELECT 1 FROM verses WHERE song_id = d.keep_id);
+
+DELETE FROM songs a
+USING songs b
+WHERE a.artist_id = b.artist_id AND lower(a.song) = lower(b.song) AND a.id > b.id;
+
+CREATE UNIQUE INDEX artists_name_lower_key ON artists (lower(name));
+
+CREATE UNIQUE INDEX songs_artist_song_key ON songs (artist_id, lower(song));
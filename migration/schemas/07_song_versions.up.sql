@@ -0,0 +1,3 @@
+-- Every change of a song bumps its version, which is its ETag for the
+-- conditional updates and deletes of the API.
+ALTER TABLE songs ADD COLUMN version INT NOT NULL DEFAULT 1;
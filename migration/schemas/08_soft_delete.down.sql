@@ -0,0 +1,9 @@
+DELETE FROM songs WHERE deleted_at IS NOT NULL;
+
+DROP INDEX songs_deleted_at_idx;
+
+DROP INDEX songs_artist_song_key;
+
+CREATE UNIQUE INDEX songs_artist_song_key ON songs (artist_id, lower(song));
+
+ALTER TABLE songs DROP COLUMN deleted_at;
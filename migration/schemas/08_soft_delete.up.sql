@@ -0,0 +1,9 @@
+-- Deleted songs stay in the trash until they are purged. They no longer
+-- take their title, so the same song can be added again.
+ALTER TABLE songs ADD COLUMN deleted_at TIMESTAMPTZ;
+
+DROP INDEX songs_artist_song_key;
+
+CREATE UNIQUE INDEX songs_artist_song_key ON songs (artist_id, lower(song)) WHERE deleted_at IS NULL;
+
+CREATE INDEX songs_deleted_at_idx ON songs (deleted_at) WHERE deleted_at IS NOT NULL;
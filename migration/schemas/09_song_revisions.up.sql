@@ -0,0 +1,14 @@
+-- Every change of a song with the song before and after it. rev is the
+-- version the change brought the song to, old_value is NULL when the song
+-- appeared and new_value when it was deleted.
+CREATE TABLE song_revisions (
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    rev INT NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    old_value JSONB,
+    new_value JSONB,
+    actor TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, rev)
+);
@@ -0,0 +1,14 @@
+-- Static API keys. Only the SHA-256 of a key is stored, the key itself is
+-- shown once when it is created. Revoked keys are kept for the record.
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('read-only', 'editor', 'admin')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+-- The name is the actor of the changes made with the key, it is unique
+-- among the keys in use.
+CREATE UNIQUE INDEX api_keys_name_idx ON api_keys (name) WHERE revoked_at IS NULL;
@@ -0,0 +1,10 @@
+-- Token buckets of the rate limiter shared by the replicas, by client and
+-- class of routes. Buckets idle for longer than the limit period are full
+-- and get deleted.
+CREATE TABLE rate_limits (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX rate_limits_updated_at_idx ON rate_limits (updated_at);
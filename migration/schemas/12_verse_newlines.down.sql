@@ -0,0 +1,3 @@
+UPDATE verses
+SET text = replace(text, E'\n', '\n')
+WHERE text LIKE E'%\n%';
@@ -0,0 +1,5 @@
+-- Verses migrated from the old blob kept the line breaks of the song info
+-- API as literal "\n" escapes. Lyrics are stored with real newlines now.
+UPDATE verses
+SET text = regexp_replace(text, '(\\r)?\\n', E'\n', 'g')
+WHERE text ~ '\\[rn]';
@@ -0,0 +1,5 @@
+DELETE FROM song_revisions r
+WHERE NOT EXISTS (SELECT 1 FROM songs s WHERE s.id = r.song_id);
+
+ALTER TABLE song_revisions
+    ADD CONSTRAINT song_revisions_song_id_fkey FOREIGN KEY (song_id) REFERENCES songs (id) ON DELETE CASCADE;
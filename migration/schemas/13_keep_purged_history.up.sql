@@ -0,0 +1,3 @@
+-- The history of a song outlives it: purging the trash records a last
+-- 'purge' revision instead of dropping the song's revisions with it.
+ALTER TABLE song_revisions DROP CONSTRAINT song_revisions_song_id_fkey;